@@ -0,0 +1,129 @@
+// Package apikey issues, revokes and verifies hashed API keys used by
+// service-to-service callers.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Set of errors returned by the Service and Store implementations.
+var (
+	// ErrNotFound is returned when the requested key does not exist.
+	ErrNotFound = errors.New("api key not found")
+
+	// ErrInvalidKey is returned when a secret is malformed, unknown or
+	// revoked. The cases are not distinguished to avoid leaking which keys
+	// exist.
+	ErrInvalidKey = errors.New("invalid api key")
+)
+
+// Issue creates a new key and returns it together with its secret. The
+// secret can not be recovered later.
+func (s *Service) Issue(ctx context.Context, nk NewKey) (Key, string, error) {
+
+	// Generate the id and secret
+	id, err := random(8)
+	if err != nil {
+		return Key{}, "", err
+	}
+	token, err := random(32)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret := secretPrefix + hex.EncodeToString(id) + "_" + base64.RawURLEncoding.EncodeToString(token)
+
+	// Store only the hash
+	k := Key{
+		ID:        hex.EncodeToString(id),
+		Name:      nk.Name,
+		Hash:      hash(secret),
+		Scopes:    nk.Scopes,
+		Roles:     nk.Roles,
+		RateLimit: nk.RateLimit,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Store.Create(ctx, k); err != nil {
+		return Key{}, "", err
+	}
+	s.Log.Printf("apikey: issued key [%v] named [%v]", k.ID, k.Name)
+	return k, secret, nil
+
+}
+
+// List returns all keys, including revoked ones
+func (s *Service) List(ctx context.Context) ([]Key, error) {
+	return s.Store.List(ctx)
+}
+
+// Revoke stops a key from authenticating. Revoking a revoked key is a no-op.
+func (s *Service) Revoke(ctx context.Context, id string) error {
+
+	k, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if k.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	k.RevokedAt = &now
+	if err := s.Store.Update(ctx, k); err != nil {
+		return err
+	}
+	s.Log.Printf("apikey: revoked key [%v] named [%v]", k.ID, k.Name)
+	return nil
+
+}
+
+// Authenticate returns the active key matching secret
+func (s *Service) Authenticate(ctx context.Context, secret string) (Key, error) {
+
+	// The id is embedded in the secret: ysk_<id>_<token>
+	rest, ok := strings.CutPrefix(secret, secretPrefix)
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return Key{}, ErrInvalidKey
+	}
+
+	k, err := s.Store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return Key{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash(secret))) != 1 || k.RevokedAt != nil {
+		return Key{}, ErrInvalidKey
+	}
+	return k, nil
+
+}
+
+// hash returns the hex encoded SHA-256 of the secret. Secrets are random so
+// a fast hash is enough, there is nothing to brute force.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// random returns n cryptographically random bytes.
+func random(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "generating random bytes")
+	}
+	return b, nil
+}
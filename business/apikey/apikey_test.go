@@ -0,0 +1,134 @@
+package apikey_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"dev/yourservice.git/business/apikey"
+	apikey_db "dev/yourservice.git/thirdparty/apikey-db"
+	"encoding/hex"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// newService returns a Service keeping keys in memory.
+func newService(t *testing.T) *apikey.Service {
+	t.Helper()
+	logger := log.New(io.Discard, "", 0)
+	db, err := apikey_db.NewClient(logger, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &apikey.Service{Log: logger, Store: db}
+}
+
+func TestIssue(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+
+	k, secret, err := s.Issue(ctx, apikey.NewKey{Name: "ci", Scopes: []string{"entities:read"}})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	if !strings.HasPrefix(secret, "ysk_"+k.ID+"_") {
+		t.Fatalf("secret [%v] does not embed the id [%v]", secret, k.ID)
+	}
+
+	// Only the SHA-256 of the secret is stored
+	sum := sha256.Sum256([]byte(secret))
+	stored, err := s.Store.Get(ctx, k.ID)
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if stored.Hash != hex.EncodeToString(sum[:]) || strings.Contains(stored.Hash, secret) {
+		t.Fatalf("stored hash [%v] is not the SHA-256 of the secret", stored.Hash)
+	}
+
+	_, other, err := s.Issue(ctx, apikey.NewKey{Name: "ci"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	if other == secret {
+		t.Fatal("issued the same secret twice")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+
+	k, secret, err := s.Issue(ctx, apikey.NewKey{Name: "ci"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	revoked, revokedSecret, err := s.Issue(ctx, apikey.NewKey{Name: "old"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	if err := s.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"valid", secret, false},
+		{"wrong token", secret + "x", true},
+		{"unknown id", "ysk_0000000000000000_" + secret[len("ysk_"+k.ID+"_"):], true},
+		{"no prefix", strings.TrimPrefix(secret, "ysk_"), true},
+		{"no token", "ysk_" + k.ID, true},
+		{"revoked", revokedSecret, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Authenticate(ctx, tt.secret)
+			if tt.wantErr {
+				if !errors.Is(err, apikey.ErrInvalidKey) {
+					t.Fatalf("got %v, want ErrInvalidKey", err)
+				}
+				return
+			}
+			if err != nil || got.ID != k.ID {
+				t.Fatalf("got key [%v] %v, want [%v]", got.ID, err, k.ID)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+
+	k, _, err := s.Issue(ctx, apikey.NewKey{Name: "ci"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	if err := s.Revoke(ctx, k.ID); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+	first, err := s.Store.Get(ctx, k.ID)
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+
+	// Revoking again keeps the first revocation time
+	if err := s.Revoke(ctx, k.ID); err != nil {
+		t.Fatalf("revoking again: %v", err)
+	}
+	again, err := s.Store.Get(ctx, k.ID)
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if first.RevokedAt == nil || !again.RevokedAt.Equal(*first.RevokedAt) {
+		t.Fatalf("revoked at %v then %v", first.RevokedAt, again.RevokedAt)
+	}
+
+	if err := s.Revoke(ctx, "missing"); !errors.Is(err, apikey.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+}
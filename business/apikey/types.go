@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"context"
+	"dev/yourservice.git/business/i"
+	"time"
+)
+
+// Constants
+const (
+	// secretPrefix starts every issued secret so leaked keys are easy to
+	// spot by secret scanners.
+	secretPrefix = "ysk_"
+)
+
+// Service encapsulates API key functionality
+type Service struct {
+	Log   i.Logger
+	Store Store
+}
+
+// Key is an API key issued to a service-to-service caller. Only the SHA-256
+// Hash of the secret is kept, the secret itself is returned once by Issue.
+// RateLimit is the number of requests allowed per minute, 0 means
+// unlimited.
+type Key struct {
+	ID        string     `json:"ID"`
+	Name      string     `json:"Name"`
+	Hash      string     `json:"Hash"`
+	Scopes    []string   `json:"Scopes"`
+	Roles     []string   `json:"Roles"`
+	RateLimit int        `json:"RateLimit"`
+	CreatedAt time.Time  `json:"CreatedAt"`
+	RevokedAt *time.Time `json:"RevokedAt,omitempty"`
+}
+
+// NewKey contains the information needed to issue a Key.
+type NewKey struct {
+	Name      string   `json:"Name" validate:"required,max=128"`
+	Scopes    []string `json:"Scopes"`
+	Roles     []string `json:"Roles"`
+	RateLimit int      `json:"RateLimit" validate:"min=0"`
+}
+
+// Store encapsulates third-party dependencies
+type Store interface {
+	Create(ctx context.Context, k Key) error
+	Get(ctx context.Context, id string) (Key, error)
+	Update(ctx context.Context, k Key) error
+	List(ctx context.Context) ([]Key, error)
+}
@@ -0,0 +1,73 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/ratelimit"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// APIKey authenticates callers presenting an X-API-Key header. The key's
+// scopes and roles are stored on the context as auth.Claims with the subject
+// apikey:<id>, so Authenticate lets the request through and the same
+// authorization rules apply as for bearer tokens. Requests without the
+// header are passed on unchanged for Authenticate to check their bearer token
+// unless required is set, when no bearer authentication follows, and they get
+// a 401. Keys with a RateLimit get a 429 once they exceed it within a sliding
+// minute, counted in limits.
+func APIKey(keys *apikey.Service, limits ratelimit.Store, required bool) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			secret := r.Header.Get("X-API-Key")
+			if secret == "" {
+				if required {
+					return web.NewRequestError(errors.New("api key required"), http.StatusUnauthorized)
+				}
+				return handler(ctx, w, r)
+			}
+
+			// Verify the key
+			k, err := keys.Authenticate(ctx, secret)
+			if err != nil {
+				if errors.Is(err, apikey.ErrInvalidKey) {
+					return web.NewRequestError(err, http.StatusUnauthorized)
+				}
+				return err
+			}
+
+			// Apply the key's rate limit
+			limit := ratelimit.Limit{
+				Algorithm: ratelimit.SlidingWindow,
+				Requests:  k.RateLimit,
+				Period:    time.Minute,
+			}
+			if err := takeLimit(ctx, w, limits, "apikey|"+k.ID, limit); err != nil {
+				return err
+			}
+
+			// Call the next handler with the key's claims on the context.
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "apikey:" + k.ID},
+				Roles:            k.Roles,
+				Scope:            strings.Join(k.Scopes, " "),
+			}
+			return handler(auth.SetClaims(ctx, claims), w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,87 @@
+package mid_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/ratelimit"
+	"dev/yourservice.git/foundation/web"
+	apikey_db "dev/yourservice.git/thirdparty/apikey-db"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// subject responds with the subject of the caller's claims.
+func subject(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, _ := auth.GetClaims(ctx)
+	return web.Respond(ctx, w, claims.Subject, http.StatusOK)
+}
+
+func TestAPIKey(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	db, err := apikey_db.NewClient(logger, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &apikey.Service{Log: logger, Store: db}
+
+	k, secret, err := keys.Issue(ctx, apikey.NewKey{Name: "ci"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	limited, limitedSecret, err := keys.Issue(ctx, apikey.NewKey{Name: "limited", RateLimit: 1})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	revoked, revokedSecret, err := keys.Issue(ctx, apikey.NewKey{Name: "old"})
+	if err != nil {
+		t.Fatalf("issuing: %v", err)
+	}
+	if err := keys.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+
+	limits := ratelimit.NewMemoryStore()
+	app := newApp()
+	app.Handle(http.MethodGet, "/required", subject, mid.APIKey(keys, limits, true))
+	app.Handle(http.MethodGet, "/optional", subject, mid.APIKey(keys, limits, false))
+
+	tests := []struct {
+		name   string
+		path   string
+		secret string
+		status int
+		body   string
+	}{
+		{"valid", "/required", secret, http.StatusOK, `"apikey:` + k.ID + `"`},
+		{"missing", "/required", "", http.StatusUnauthorized, ""},
+		{"missing passed on", "/optional", "", http.StatusOK, `""`},
+		{"invalid", "/optional", secret + "x", http.StatusUnauthorized, ""},
+		{"revoked", "/required", revokedSecret, http.StatusUnauthorized, ""},
+		{"within rate limit", "/required", limitedSecret, http.StatusOK, `"apikey:` + limited.ID + `"`},
+		{"over rate limit", "/required", limitedSecret, http.StatusTooManyRequests, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.secret != "" {
+				r.Header.Set("X-API-Key", tt.secret)
+			}
+			w := serve(app, r)
+			if w.Code != tt.status {
+				t.Fatalf("got status %v, want %v: %s", w.Code, tt.status, w.Body.Bytes())
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Fatalf("got body %s, want %s", w.Body.Bytes(), tt.body)
+			}
+			if tt.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Fatal("missing Retry-After")
+			}
+		})
+	}
+}
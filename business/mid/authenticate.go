@@ -0,0 +1,56 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/web"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Authenticate validates the bearer token in the Authorization header and
+// stores its verified claims on the context, where they can be retrieved
+// with auth.GetClaims. Requests without a valid token get a 401 with a
+// WWW-Authenticate challenge. Requests already authenticated by APIKey are
+// passed on unchanged.
+func Authenticate(a *auth.Auth) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			// The caller was authenticated by an earlier middleware
+			if _, ok := auth.GetClaims(ctx); ok {
+				return handler(ctx, w, r)
+			}
+
+			// Expecting: Bearer <token>
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				return web.NewRequestError(errors.New("authentication required"), http.StatusUnauthorized)
+			}
+
+			// Validate the token
+			claims, err := a.Validate(strings.TrimSpace(token))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+					"Bearer error=%q, error_description=%q", "invalid_token", "the access token is invalid",
+				))
+				return web.NewRequestError(errors.Wrap(err, "invalid token"), http.StatusUnauthorized)
+			}
+
+			// Call the next handler with the claims on the context.
+			return handler(auth.SetClaims(ctx, claims), w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,76 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Authorize allows the request when the authenticated caller has at least one
+// of roles, otherwise it responds with a 403. It must run after
+// Authenticate.
+func Authorize(roles ...string) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			claims, ok := auth.GetClaims(ctx)
+			if !ok {
+				return web.NewRequestError(errors.New("authentication required"), http.StatusUnauthorized)
+			}
+			if !claims.HasAnyRole(roles...) {
+				err := errors.Wrapf(auth.ErrForbidden, "requires one of roles %v", roles)
+				return web.NewRequestError(err, http.StatusForbidden)
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizePolicy allows the request when the authenticated caller satisfies
+// the policy rule for the matched route, otherwise it responds with a 403.
+// It must run after Authenticate.
+func AuthorizePolicy(policy *auth.Policy) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			// If the context is missing this value, request the service
+			// to be shutdown gracefully.
+			v, ok := ctx.Value(web.KeyValues).(*web.Values)
+			if !ok {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			claims, ok := auth.GetClaims(ctx)
+			if !ok {
+				return web.NewRequestError(errors.New("authentication required"), http.StatusUnauthorized)
+			}
+			if err := policy.Check(r.Method, v.Route, claims); err != nil {
+				return web.NewRequestError(err, http.StatusForbidden)
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,129 @@
+package mid_test
+
+import (
+	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/auth"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// secret signs the tokens of the tests.
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// newAuth returns an Auth validating tokens signed with secret.
+func newAuth() *auth.Auth {
+	var keys auth.KeySet
+	keys.Add("test", auth.Key{Alg: jwt.SigningMethodHS256.Alg(), Key: secret})
+	return auth.New(&keys, auth.Config{})
+}
+
+// token returns a bearer token with roles and scope signed with secret.
+func token(t *testing.T, roles []string, scope string) string {
+	t.Helper()
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: roles,
+		Scope: scope,
+	}
+	tkn := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tkn.Header["kid"] = "test"
+	s, err := tkn.SignedString(secret)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return "Bearer " + s
+}
+
+func TestAuthorizePolicy(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "policy.json")
+	data := `{"defaultDeny": true, "rules": [
+		{"method": "GET", "path": "/entities/:id", "scopes": ["entities:read"]},
+		{"method": "DELETE", "path": "/entities/:id", "roles": ["admin"], "scopes": ["entities:write"]}
+	]}`
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := auth.LoadPolicy(name)
+	if err != nil {
+		t.Fatalf("loading policy: %v", err)
+	}
+
+	app := newApp()
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		app.Handle(method, "/entities/:id", ok, mid.Authenticate(newAuth()), mid.AuthorizePolicy(policy))
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		status        int
+		message       string
+	}{
+		{"no token", http.MethodGet, "", http.StatusUnauthorized, "authentication required"},
+		{"invalid token", http.MethodGet, "Bearer not.a.token", http.StatusUnauthorized, "invalid token"},
+		{"scope held", http.MethodGet, token(t, nil, "entities:read"), http.StatusOK, ""},
+		{"scope missing", http.MethodGet, token(t, []string{"admin"}, ""), http.StatusForbidden, "requires scopes [entities:read]"},
+		{"role and scope held", http.MethodDelete, token(t, []string{"admin"}, "entities:write"), http.StatusOK, ""},
+		{"role missing", http.MethodDelete, token(t, []string{"viewer"}, "entities:write"), http.StatusForbidden, "requires one of roles [admin]"},
+		{"no rule", http.MethodPut, token(t, []string{"admin"}, "entities:write"), http.StatusForbidden, "no policy for [PUT /entities/:id]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/entities/a", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := serve(app, r)
+			if w.Code != tt.status {
+				t.Fatalf("got status %v, want %v: %s", w.Code, tt.status, w.Body.Bytes())
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+			if msg := errorMessage(t, w); !strings.HasPrefix(msg, tt.message) {
+				t.Fatalf("got message [%v], want [%v]", msg, tt.message)
+			}
+			if tt.status == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Fatalf("got challenge [%v], want Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	app := newApp()
+	app.Handle(http.MethodGet, "/admin", ok, mid.Authenticate(newAuth()), mid.Authorize("admin", "operator"))
+	app.Handle(http.MethodGet, "/unauthenticated", ok, mid.Authorize("admin"))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+	}{
+		{"one of the roles", "/admin", token(t, []string{"viewer", "operator"}, ""), http.StatusOK},
+		{"none of the roles", "/admin", token(t, []string{"viewer"}, ""), http.StatusForbidden},
+		{"no roles", "/admin", token(t, nil, "entities:read"), http.StatusForbidden},
+		{"without Authenticate", "/unauthenticated", token(t, []string{"admin"}, ""), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Authorization", tt.authorization)
+			if w := serve(app, r); w.Code != tt.status {
+				t.Fatalf("got status %v, want %v: %s", w.Code, tt.status, w.Body.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CORSConfig describes which cross origin requests browsers may make.
+type CORSConfig struct {
+
+	// AllowedOrigins are the origins allowed to make requests. "*" allows
+	// any origin and a single "*" inside an origin matches any subdomain,
+	// e.g. "https://*.example.com".
+	AllowedOrigins []string
+
+	// AllowedMethods are the methods allowed in preflight requests.
+	AllowedMethods []string
+
+	// AllowedHeaders are the request headers allowed in preflight requests,
+	// "*" allows any header.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers readable by the browser.
+	ExposedHeaders []string
+
+	// AllowCredentials lets the browser send cookies and authorization
+	// headers. The matched origin is echoed instead of "*" when set, so it
+	// cannot be combined with the "*" origin.
+	AllowCredentials bool
+
+	// MaxAge is how long the browser may cache a preflight response, zero
+	// leaves the browser default.
+	MaxAge time.Duration
+}
+
+// Validate reports configurations that would expose the API to any site.
+// Allowing credentials for the "*" origin lets every site make
+// authenticated requests with the user's cookies.
+func (cfg CORSConfig) Validate() error {
+	if cfg.AllowCredentials && contains(cfg.AllowedOrigins, "*") {
+		return errors.New("the * origin cannot be allowed together with credentials")
+	}
+	return nil
+}
+
+// CORS sets the CORS headers of cross origin requests from allowed origins.
+// Preflight requests are answered by the App, this middleware only adds the
+// headers so it must be part of the App's general middleware. Credentials
+// are never allowed for a configuration failing Validate.
+func CORS(cfg CORSConfig) web.Middleware {
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	anyOrigin := contains(cfg.AllowedOrigins, "*")
+	credentials := cfg.AllowCredentials && cfg.Validate() == nil
+	anyHeader := contains(cfg.AllowedHeaders, "*")
+	headers := make(map[string]bool, len(cfg.AllowedHeaders))
+	for _, h := range cfg.AllowedHeaders {
+		headers[strings.ToLower(h)] = true
+	}
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// Responses differ by origin unless every origin gets the same
+			// answer.
+			if !anyOrigin || credentials {
+				w.Header().Add("Vary", "Origin")
+			}
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+			if origin == "" || !(anyOrigin || matchOrigin(cfg.AllowedOrigins, origin)) {
+				return handler(ctx, w, r)
+			}
+
+			// A preflight must ask for an allowed method and headers,
+			// otherwise the browser is left to block the request.
+			if preflight {
+				if !contains(cfg.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+					return handler(ctx, w, r)
+				}
+				requested := r.Header.Get("Access-Control-Request-Headers")
+				for _, rh := range strings.Split(requested, ",") {
+					rh = strings.ToLower(strings.TrimSpace(rh))
+					if rh != "" && !anyHeader && !headers[rh] {
+						return handler(ctx, w, r)
+					}
+				}
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				if requested != "" {
+					w.Header().Set("Access-Control-Allow-Headers", requested)
+				}
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+				}
+			} else if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+
+			if anyOrigin && !credentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// matchOrigin reports whether origin matches one of the allowed origins,
+// which may contain a single "*" wildcard.
+func matchOrigin(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		prefix, suffix, wildcard := strings.Cut(a, "*")
+		if !wildcard {
+			if a == origin {
+				return true
+			}
+			continue
+		}
+		if len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether s is in list, ignoring case.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
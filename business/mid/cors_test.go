@@ -0,0 +1,140 @@
+package mid_test
+
+import (
+	"dev/yourservice.git/business/mid"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	listed := mid.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+	credentials := listed
+	credentials.AllowCredentials = true
+	wildcard := mid.CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{http.MethodGet}}
+	wildcardCredentials := wildcard
+	wildcardCredentials.AllowCredentials = true
+
+	tests := []struct {
+		name        string
+		cfg         mid.CORSConfig
+		method      string
+		header      http.Header
+		origin      string
+		credentials bool
+		varyOrigin  bool
+		want        http.Header
+	}{
+		{"allowed origin", listed, http.MethodGet, http.Header{"Origin": {"https://app.example.com"}},
+			"https://app.example.com", false, true, http.Header{"Access-Control-Expose-Headers": {"ETag"}}},
+		{"allowed origin ignoring case", listed, http.MethodGet, http.Header{"Origin": {"https://APP.example.com"}},
+			"https://APP.example.com", false, true, nil},
+		{"subdomain wildcard", listed, http.MethodGet, http.Header{"Origin": {"https://a.b.example.org"}},
+			"https://a.b.example.org", false, true, nil},
+		{"bare domain of wildcard", listed, http.MethodGet, http.Header{"Origin": {"https://example.org"}},
+			"", false, true, nil},
+		{"disallowed origin", listed, http.MethodGet, http.Header{"Origin": {"https://evil.example.net"}},
+			"", false, true, nil},
+		{"same origin", listed, http.MethodGet, nil,
+			"", false, true, nil},
+		{"preflight", listed, http.MethodOptions, http.Header{
+			"Origin":                         {"https://app.example.com"},
+			"Access-Control-Request-Method":  {"PUT"},
+			"Access-Control-Request-Headers": {"content-type, authorization"},
+		}, "https://app.example.com", false, true, http.Header{
+			"Access-Control-Allow-Methods": {"GET, PUT"},
+			"Access-Control-Allow-Headers": {"content-type, authorization"},
+			"Access-Control-Max-Age":       {"600"},
+			"Allow":                        {"OPTIONS, GET, PUT"},
+		}},
+		{"preflight disallowed method", listed, http.MethodOptions, http.Header{
+			"Origin":                        {"https://app.example.com"},
+			"Access-Control-Request-Method": {"DELETE"},
+		}, "", false, true, nil},
+		{"preflight disallowed header", listed, http.MethodOptions, http.Header{
+			"Origin":                         {"https://app.example.com"},
+			"Access-Control-Request-Method":  {"GET"},
+			"Access-Control-Request-Headers": {"X-Other"},
+		}, "", false, true, nil},
+		{"credentials", credentials, http.MethodGet, http.Header{"Origin": {"https://app.example.com"}},
+			"https://app.example.com", true, true, nil},
+		{"any origin", wildcard, http.MethodGet, http.Header{"Origin": {"https://any.example.net"}},
+			"*", false, false, nil},
+		{"credentials with any origin", wildcardCredentials, http.MethodGet, http.Header{"Origin": {"https://any.example.net"}},
+			"*", false, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp(mid.CORS(tt.cfg))
+			app.Handle(http.MethodGet, "/entities/:id", ok)
+			app.Handle(http.MethodPut, "/entities/:id", ok)
+
+			r := httptest.NewRequest(tt.method, "/entities/a", nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			w := serve(app, r)
+
+			want := http.StatusOK
+			if tt.method == http.MethodOptions {
+				want = http.StatusNoContent
+			}
+			if w.Code != want {
+				t.Fatalf("got status %v, want %v", w.Code, want)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.origin {
+				t.Fatalf("got allowed origin [%v], want [%v]", got, tt.origin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.credentials {
+				t.Fatalf("got credentials [%v], want [%v]", got, tt.credentials)
+			}
+			if got := contains(w.Header().Values("Vary"), "Origin"); got != tt.varyOrigin {
+				t.Fatalf("got Vary %v, want Origin [%v]", w.Header().Values("Vary"), tt.varyOrigin)
+			}
+			for name := range tt.want {
+				if got := w.Header().Get(name); got != tt.want.Get(name) {
+					t.Fatalf("got %v [%v], want [%v]", name, got, tt.want.Get(name))
+				}
+			}
+		})
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     mid.CORSConfig
+		wantErr bool
+	}{
+		{"listed origins with credentials", mid.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}, false},
+		{"any origin", mid.CORSConfig{AllowedOrigins: []string{"*"}}, false},
+		{"any origin with credentials", mid.CORSConfig{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error [%v]", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// contains reports whether s is one of the comma separated values.
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if strings.TrimSpace(item) == s {
+				return true
+			}
+		}
+	}
+	return false
+}
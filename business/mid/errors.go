@@ -2,16 +2,19 @@ package mid
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 
-	"dev/yourservice.git/business/i"
 	"dev/yourservice.git/foundation/web"
+	"github.com/pkg/errors"
 )
 
 // Errors handles errors coming out of the call chain. It detects normal
 // application errors which are used to respond to the client in a uniform way.
-// Unexpected errors (status >= 500) are logged.
-func Errors(log i.Logger) web.Middleware {
+// Errors recognised by errs are translated to their registered status code,
+// anything else that is not a *web.Error becomes a 500. All errors are logged,
+// unexpected errors (status >= 500) at error level and the rest at warn.
+func Errors(log *slog.Logger, errs *web.ErrorMap) web.Middleware {
 
 	// This is the actual middleware function to be executed.
 	m := func(handler web.Handler) web.Handler {
@@ -28,12 +31,29 @@ func Errors(log i.Logger) web.Middleware {
 
 			// Run the next handler and catch any propagated error.
 			if err := handler(ctx, w, r); err != nil {
+				mapped := errs.Map(err)
 
-				// Log the error.
-				log.Printf("[%v]: ERROR     : [%v]", v.TraceID, err)
+				// Log the error with the status it will be responded with,
+				// before responding so it is recorded even if that fails.
+				status := http.StatusInternalServerError
+				var webErr *web.Error
+				if errors.As(mapped, &webErr) {
+					status = webErr.StatusCode
+				}
+				level := slog.LevelWarn
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+				log.Log(ctx, level, "request failed",
+					"trace_id", v.TraceID,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"status", status,
+					"error", err.Error(),
+				)
 
 				// Respond to the error.
-				if err := web.RespondError(ctx, w, err); err != nil {
+				if err := web.RespondError(ctx, w, mapped); err != nil {
 					return err
 				}
 
@@ -0,0 +1,176 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"dev/yourservice.git/foundation/idempotency"
+	"dev/yourservice.git/foundation/web"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxIdempotencyKey is the longest Idempotency-Key accepted.
+const maxIdempotencyKey = 255
+
+// IdempotencyConfig describes where and for how long responses are kept.
+type IdempotencyConfig struct {
+	Store idempotency.Store
+	TTL   time.Duration
+
+	// Caller identifies the caller the keys belong to, it defaults to
+	// RateLimitBySubject(RateLimitByIP).
+	Caller RateLimitKey
+}
+
+// Idempotency replays the stored response of a request made with the same
+// Idempotency-Key by the same caller instead of running the handler again.
+// Reusing a key with a different request is a 422 and retrying while the
+// first request is in flight is a 409. Requests without the header are passed
+// on unchanged.
+//
+// Only responses written by the handler are stored, when it returns an error
+// the key is released so the request can be retried. Headers set before this
+// middleware runs, such as the CORS and RateLimit-* headers, are not stored
+// so a replay carries the ones of the retry. The caller is the
+// authenticated subject or client IP, so it must run after Authenticate and
+// APIKey.
+func Idempotency(cfg IdempotencyConfig) web.Middleware {
+
+	caller := cfg.Caller
+	if caller == nil {
+		caller = RateLimitBySubject(RateLimitByIP)
+	}
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			header := r.Header.Get("Idempotency-Key")
+			if header == "" {
+				return handler(ctx, w, r)
+			}
+			if len(header) > maxIdempotencyKey {
+				err := errors.Errorf("Idempotency-Key longer than [%v] characters", maxIdempotencyKey)
+				return web.NewRequestError(err, http.StatusBadRequest)
+			}
+			v, ok := ctx.Value(web.KeyValues).(*web.Values)
+			if !ok {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			// Fingerprint the request, the body is restored for the handler.
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var mbe *http.MaxBytesError
+				if errors.As(err, &mbe) {
+					err := errors.Errorf("body must not be larger than %d bytes", mbe.Limit)
+					return web.NewRequestError(err, http.StatusRequestEntityTooLarge)
+				}
+				return web.NewRequestError(errors.Wrap(err, "reading body"), http.StatusBadRequest)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			// Reserve the key or answer from the first request.
+			key := caller(ctx, r) + "|" + header
+			rec, reserved, err := cfg.Store.Reserve(ctx, key, fingerprint, cfg.TTL)
+			if err != nil {
+				return errors.Wrap(err, "reserving idempotency key")
+			}
+			if !reserved {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					err := errors.New("Idempotency-Key was already used for a different request")
+					return web.NewRequestError(err, http.StatusUnprocessableEntity)
+				case !rec.Done:
+					err := errors.New("a request with this Idempotency-Key is in progress")
+					return web.NewRequestError(err, http.StatusConflict)
+				}
+				for k, vals := range rec.Header {
+					w.Header()[k] = vals
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				v.StatusCode = rec.StatusCode
+				w.WriteHeader(rec.StatusCode)
+				_, err := w.Write(rec.Body)
+				return err
+			}
+
+			// Release the key unless the response is stored, also when the
+			// handler panics.
+			completed := false
+			defer func() {
+				if !completed {
+					_ = cfg.Store.Release(context.WithoutCancel(ctx), key)
+				}
+			}()
+
+			// Call the next handler recording what it writes.
+			rw := recorder{ResponseWriter: w, before: w.Header().Clone()}
+			if err := handler(ctx, &rw, r); err != nil {
+				return err
+			}
+			if rw.status == 0 {
+				return nil
+			}
+
+			err = cfg.Store.Complete(ctx, key, idempotency.Record{
+				Fingerprint: fingerprint,
+				StatusCode:  rw.status,
+				Header:      rw.header,
+				Body:        rw.body.Bytes(),
+			}, cfg.TTL)
+			if err != nil {
+				return errors.Wrap(err, "storing idempotent response")
+			}
+			completed = true
+			return nil
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// recorder is a ResponseWriter keeping a copy of the response. Only the
+// headers changed since before are kept.
+type recorder struct {
+	http.ResponseWriter
+	before http.Header
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status and the headers set by the handler.
+func (rw *recorder) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+		rw.header = make(http.Header)
+		for k, vals := range rw.Header() {
+			if !slices.Equal(vals, rw.before[k]) {
+				rw.header[k] = slices.Clone(vals)
+			}
+		}
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body.
+func (rw *recorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,174 @@
+package mid_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/idempotency"
+	"dev/yourservice.git/foundation/web"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// idempotentApp returns an App creating entities behind the Idempotency
+// middleware. A general middleware numbers every request in the X-Request
+// header, the handler numbers the entities it creates and fails for a body
+// of "fail", and a body of "block" waits for release after closing started.
+func idempotentApp(started chan<- struct{}, release <-chan struct{}) (*web.App, *int32) {
+	var requests, created int32
+	number := func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("X-Request", fmt.Sprint(atomic.AddInt32(&requests, 1)))
+			return handler(ctx, w, r)
+		}
+	}
+	create := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		switch string(body) {
+		case "fail":
+			return web.NewRequestError(errors.New("failed"), http.StatusServiceUnavailable)
+		case "block":
+			close(started)
+			<-release
+		}
+		n := atomic.AddInt32(&created, 1)
+		w.Header().Set("Location", fmt.Sprintf("/entities/%v", n))
+		return web.Respond(ctx, w, n, http.StatusCreated)
+	}
+
+	app := newApp(number)
+	app.Handle(http.MethodPost, "/entities", create, mid.Idempotency(mid.IdempotencyConfig{
+		Store: idempotency.NewMemoryStore(),
+		TTL:   time.Hour,
+	}))
+	return app, &created
+}
+
+// post sends body to the App with key as the Idempotency-Key from ip.
+func post(app http.Handler, ip string, key string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/entities", strings.NewReader(body))
+	r.RemoteAddr = ip + ":1234"
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	return serve(app, r)
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	app, created := idempotentApp(nil, nil)
+
+	first := post(app, "10.0.0.1", "k1", "a")
+	if first.Code != http.StatusCreated || first.Body.String() != "1" {
+		t.Fatalf("got %v %s, want 201 and the first entity", first.Code, first.Body.Bytes())
+	}
+
+	// The retry gets the first response with the headers of its own request
+	retry := post(app, "10.0.0.1", "k1", "a")
+	if retry.Code != http.StatusCreated || retry.Body.String() != "1" {
+		t.Fatalf("got %v %s, want the first response", retry.Code, retry.Body.Bytes())
+	}
+	headers := []struct{ name, want string }{
+		{"Idempotent-Replayed", "true"},
+		{"Location", "/entities/1"},
+		{"Content-Type", "application/json; charset=utf-8"},
+		{"X-Request", "2"},
+	}
+	for _, h := range headers {
+		if got := retry.Header().Values(h.name); len(got) != 1 || got[0] != h.want {
+			t.Fatalf("got %v %v, want [%v]", h.name, got, h.want)
+		}
+	}
+	if *created != 1 {
+		t.Fatalf("created %v entities, want 1", *created)
+	}
+
+	// Keys belong to a caller, requests without one are not replayed
+	tests := []struct {
+		name string
+		ip   string
+		key  string
+		want string
+	}{
+		{"other caller", "10.0.0.2", "k1", "2"},
+		{"other key", "10.0.0.1", "k2", "3"},
+		{"no key", "10.0.0.1", "", "4"},
+		{"no key again", "10.0.0.1", "", "5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(app, tt.ip, tt.key, "a")
+			if w.Code != http.StatusCreated || w.Body.String() != tt.want || w.Header().Get("Idempotent-Replayed") != "" {
+				t.Fatalf("got %v %s replayed [%v], want a new entity %v", w.Code, w.Body.Bytes(), w.Header().Get("Idempotent-Replayed"), tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyDifferentRequest(t *testing.T) {
+	app, _ := idempotentApp(nil, nil)
+
+	if w := post(app, "10.0.0.1", "k1", "a"); w.Code != http.StatusCreated {
+		t.Fatalf("got status %v, want 201", w.Code)
+	}
+	w := post(app, "10.0.0.1", "k1", "b")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %v, want 422", w.Code)
+	}
+	if msg := errorMessage(t, w); msg != "Idempotency-Key was already used for a different request" {
+		t.Fatalf("got message [%v]", msg)
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	app, _ := idempotentApp(started, release)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- post(app, "10.0.0.1", "k1", "block")
+	}()
+	<-started
+
+	w := post(app, "10.0.0.1", "k1", "block")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("got status %v, want 409", w.Code)
+	}
+	if msg := errorMessage(t, w); msg != "a request with this Idempotency-Key is in progress" {
+		t.Fatalf("got message [%v]", msg)
+	}
+
+	close(release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Fatalf("got status %v for the first request, want 201", w.Code)
+	}
+}
+
+func TestIdempotencyError(t *testing.T) {
+	app, created := idempotentApp(nil, nil)
+
+	// A failed request releases the key for the retry
+	if w := post(app, "10.0.0.1", "k1", "fail"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got status %v, want 503", w.Code)
+	}
+	if w := post(app, "10.0.0.1", "k1", "fail"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("got status %v replayed [%v], want the handler to run again", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if *created != 0 {
+		t.Fatalf("created %v entities, want none", *created)
+	}
+
+	long := strings.Repeat("k", 256)
+	if w := post(app, "10.0.0.1", long, "a"); w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %v for a long key, want 400", w.Code)
+	}
+}
@@ -2,15 +2,15 @@ package mid
 
 import (
 	"context"
-	"dev/yourservice.git/business/i"
 	"dev/yourservice.git/foundation/web"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
-// Logger writes some information about the request to the logs in the
-// format: TraceID : (200) GET /foo -> IP ADDR (latency)
-func Logger(log i.Logger) web.Middleware {
+// Logger writes the start and completion of every request to the logs with
+// the trace_id, method, path, remote_addr, status and latency as fields.
+func Logger(log *slog.Logger) web.Middleware {
 
 	// This is the actual middleware function to be executed.
 	m := func(handler web.Handler) web.Handler {
@@ -25,18 +25,23 @@ func Logger(log i.Logger) web.Middleware {
 				return web.NewShutdownError("web value missing from context")
 			}
 
-			log.Printf("[%v]: started   : [%v] [%v] -> [%v]",
-				v.TraceID,
-				r.Method, r.URL.Path, r.RemoteAddr,
+			log.InfoContext(ctx, "request started",
+				"trace_id", v.TraceID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
 			)
 
 			// Call the next handler.
 			err := handler(ctx, w, r)
 
-			log.Printf("[%v]: completed : [%v] [%v] -> [%v] ([%v]) ([%v])",
-				v.TraceID,
-				r.Method, r.URL.Path, r.RemoteAddr,
-				v.StatusCode, time.Since(v.Now),
+			log.InfoContext(ctx, "request completed",
+				"trace_id", v.TraceID,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"status", v.StatusCode,
+				"latency", time.Since(v.Now),
 			)
 
 			// Return the error so it can be handled further up the chain.
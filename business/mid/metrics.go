@@ -0,0 +1,66 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics records the number, latency and concurrency of requests in reg. All
+// series are labelled by route pattern rather than raw path to keep their
+// cardinality bounded. Panics are counted as 500s once Errors has responded,
+// so Metrics must run before Errors in the middleware chain.
+func Metrics(reg prometheus.Registerer) web.Middleware {
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled.",
+	}, []string{"route", "method", "status"})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	inFlight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being handled.",
+	}, []string{"route", "method"})
+	reg.MustRegister(requests, latency, inFlight)
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			// If the context is missing this value, request the service
+			// to be shutdown gracefully.
+			v, ok := ctx.Value(web.KeyValues).(*web.Values)
+			if !ok {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			gauge := inFlight.WithLabelValues(v.Route, r.Method)
+			gauge.Inc()
+			defer gauge.Dec()
+
+			// Call the next handler.
+			err := handler(ctx, w, r)
+
+			status := strconv.Itoa(v.StatusCode)
+			requests.WithLabelValues(v.Route, r.Method, status).Inc()
+			latency.WithLabelValues(v.Route, r.Method, status).Observe(time.Since(v.Now).Seconds())
+
+			// Return the error so it can be handled further up the chain.
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
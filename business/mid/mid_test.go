@@ -0,0 +1,44 @@
+package mid_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/web"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// newApp returns an App responding to errors like the service does, mw are
+// added as general middleware after the error handling.
+func newApp(mw ...web.Middleware) *web.App {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw = append([]web.Middleware{mid.Errors(log, web.NewErrorMap())}, mw...)
+	return web.NewApp(make(chan os.Signal, 1), mw...)
+}
+
+// ok responds with a 200 and an empty object.
+func ok(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, struct{}{}, http.StatusOK)
+}
+
+// serve sends r to app and returns the recorded response.
+func serve(app http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	return w
+}
+
+// errorMessage returns the message of an error response.
+func errorMessage(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var er web.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &er); err != nil {
+		t.Fatalf("decoding error response [%s]: %v", w.Body.Bytes(), err)
+	}
+	return er.Error
+}
@@ -0,0 +1,158 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"dev/yourservice.git/foundation/openapi"
+	"dev/yourservice.git/foundation/web"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// OpenAPIConfig describes the document requests are validated against.
+type OpenAPIConfig struct {
+	Validator *openapi.Validator
+
+	// ValidateResponses checks the responses written by handlers as well
+	// when running locally, web.IsDevAppServer, so handlers drifting from
+	// the document fail in development. Responses are buffered to do so.
+	ValidateResponses bool
+}
+
+// OpenAPI validates the path parameters, query, headers and body of requests
+// against the operation of an OpenAPI document they match before the handler
+// runs. Failures are a 400 listing every violation as a web.FieldError, a
+// Content-Type the operation does not accept is a 415. Requests the document
+// does not describe are passed on unchanged.
+//
+// The body is read to validate it, so routes overriding the body limit with
+// web.MaxBodyBytes must do so before this middleware runs.
+func OpenAPI(cfg OpenAPIConfig) web.Middleware {
+	validateResponses := cfg.ValidateResponses && web.IsDevAppServer()
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			match := cfg.Validator.Find(r.Method, r.URL.Path)
+			if match == nil {
+				return handler(ctx, w, r)
+			}
+
+			// Read the body, it is restored for the handler.
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				var err error
+				body, err = io.ReadAll(r.Body)
+				if err != nil {
+					var mbe *http.MaxBytesError
+					if errors.As(err, &mbe) {
+						err := errors.Errorf("body must not be larger than %d bytes", mbe.Limit)
+						return web.NewRequestError(err, http.StatusRequestEntityTooLarge)
+					}
+					return web.NewRequestError(errors.Wrap(err, "reading body"), http.StatusBadRequest)
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			// Validate the request.
+			violations, err := cfg.Validator.ValidateRequest(match, r, body)
+			switch {
+			case errors.Is(err, openapi.ErrMediaType):
+				err := errors.Errorf("Content-Type [%v] is not supported", r.Header.Get("Content-Type"))
+				return web.NewRequestError(err, http.StatusUnsupportedMediaType)
+			case errors.Is(err, openapi.ErrBodyRequired):
+				return web.NewRequestError(errors.New("body must not be empty"), http.StatusBadRequest)
+			case err != nil:
+				return web.NewRequestError(err, http.StatusBadRequest)
+			case len(violations) > 0:
+				return &web.Error{
+					Err:        errors.New("field validation error"),
+					StatusCode: http.StatusBadRequest,
+					Fields:     fieldErrors(violations),
+				}
+			}
+			if !validateResponses {
+
+				// Call the next handler.
+				return handler(ctx, w, r)
+			}
+
+			// Call the next handler buffering the response, it is only
+			// sent when it matches the document.
+			buf := buffer{header: w.Header().Clone()}
+			if err := handler(ctx, &buf, r); err != nil {
+				return err
+			}
+			status := buf.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if violations := cfg.Validator.ValidateResponse(match, status, buf.header, buf.body.Bytes()); len(violations) > 0 {
+				return &web.Error{
+					Err:        errors.Errorf("response with status [%v] does not match the OpenAPI document", status),
+					StatusCode: http.StatusInternalServerError,
+					Fields:     fieldErrors(violations),
+				}
+			}
+
+			for k := range w.Header() {
+				if _, exists := buf.header[k]; !exists {
+					w.Header().Del(k)
+				}
+			}
+			for k, vals := range buf.header {
+				w.Header()[k] = vals
+			}
+			if buf.status != 0 {
+				w.WriteHeader(buf.status)
+			}
+			_, err = w.Write(buf.body.Bytes())
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// fieldErrors converts violations into the field errors of a response.
+func fieldErrors(violations []openapi.Violation) []web.FieldError {
+	fields := make([]web.FieldError, len(violations))
+	for i, v := range violations {
+		fields[i] = web.FieldError{Field: v.Field, Error: v.Message}
+	}
+	return fields
+}
+
+// buffer is a ResponseWriter holding the response until it is validated.
+type buffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the buffered headers.
+func (b *buffer) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader records the first status.
+func (b *buffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write buffers the body.
+func (b *buffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
@@ -2,8 +2,8 @@ package mid
 
 import (
 	"context"
-	"dev/yourservice.git/business/i"
 	"dev/yourservice.git/foundation/web"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 
@@ -12,7 +12,7 @@ import (
 
 // Panics recovers from panics and converts the panic to an error so it is
 // reported in Metrics and handled in Errors.
-func Panics(log i.Logger) web.Middleware {
+func Panics(log *slog.Logger) web.Middleware {
 
 	// This is the actual middleware function to be executed.
 	m := func(handler web.Handler) web.Handler {
@@ -30,11 +30,17 @@ func Panics(log i.Logger) web.Middleware {
 			// Defer a function to recover from a panic and set the err return
 			// variable after the fact.
 			defer func() {
-				if r := recover(); r != nil {
-					err = errors.Errorf("panic: [%v]", r)
+				if rec := recover(); rec != nil {
+					err = errors.Errorf("panic: [%v]", rec)
 
 					// Log the Go stack trace for this panic'd goroutine.
-					log.Printf("[%v]: PANIC     :\n[%v]", v.TraceID, string(debug.Stack()))
+					log.ErrorContext(ctx, "panic",
+						"trace_id", v.TraceID,
+						"method", r.Method,
+						"path", r.URL.Path,
+						"panic", rec,
+						"stack", string(debug.Stack()),
+					)
 				}
 			}()
 
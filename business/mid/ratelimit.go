@@ -0,0 +1,162 @@
+package mid
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/ratelimit"
+	"dev/yourservice.git/foundation/web"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RateLimitKey identifies the caller a request is counted against.
+type RateLimitKey func(ctx context.Context, r *http.Request) string
+
+// RateLimitByIP counts requests against the client IP of the connection.
+func RateLimitByIP(ctx context.Context, r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitByForwardedIP counts requests against the client IP in header,
+// such as X-Forwarded-For, as set by the trusted proxies in front of the
+// service. Each of the proxies appends the address it received the request
+// from so the client IP is the entry proxies from the end, entries before it
+// are set by the client and not trusted. The IP of the connection is used
+// when the header has fewer entries, the request did not pass the proxies.
+func RateLimitByForwardedIP(header string, proxies int) RateLimitKey {
+	if proxies < 1 {
+		proxies = 1
+	}
+	return func(ctx context.Context, r *http.Request) string {
+		var entries []string
+		for _, value := range r.Header.Values(header) {
+			entries = append(entries, strings.Split(value, ",")...)
+		}
+		n := len(entries) - proxies
+		if n < 0 {
+			return RateLimitByIP(ctx, r)
+		}
+		ip := net.ParseIP(strings.TrimSpace(entries[n]))
+		if ip == nil {
+			return RateLimitByIP(ctx, r)
+		}
+		return "ip:" + ip.String()
+	}
+}
+
+// RateLimitBySubject counts requests against the authenticated subject, a JWT
+// subject or apikey:<id>, and against the key returned by fallback when there
+// is none. It must run after Authenticate and APIKey.
+func RateLimitBySubject(fallback RateLimitKey) RateLimitKey {
+	return func(ctx context.Context, r *http.Request) string {
+		if claims, ok := auth.GetClaims(ctx); ok && claims.Subject != "" {
+			return "sub:" + claims.Subject
+		}
+		return fallback(ctx, r)
+	}
+}
+
+// RateLimitConfig describes the limits applied by RateLimit.
+type RateLimitConfig struct {
+	Store ratelimit.Store
+
+	// Key identifies the caller, it defaults to RateLimitByIP.
+	Key RateLimitKey
+
+	// Limit applies to every route without an entry in Routes.
+	Limit ratelimit.Limit
+
+	// Routes holds limits per route, keyed by method and route pattern,
+	// for example POST /entities. Each route is counted separately.
+	Routes map[string]ratelimit.Limit
+
+	// Exempt holds the routes that are never limited, keyed like Routes,
+	// such as the health checks.
+	Exempt map[string]bool
+}
+
+// RateLimit counts requests per caller and responds with a 429 once the
+// caller exceeds its limit. Every limited response carries the RateLimit-*
+// headers, a 429 also carries Retry-After. OPTIONS requests, preflights sent
+// by browsers ahead of the actual request, are not counted.
+func RateLimit(cfg RateLimitConfig) web.Middleware {
+
+	key := cfg.Key
+	if key == nil {
+		key = RateLimitByIP
+	}
+
+	// This is the actual middleware function to be executed.
+	m := func(handler web.Handler) web.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+			v, ok := ctx.Value(web.KeyValues).(*web.Values)
+			if !ok {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			if r.Method == http.MethodOptions || cfg.Exempt[r.Method+" "+v.Route] {
+				return handler(ctx, w, r)
+			}
+
+			// Pick the route's limit over the general one.
+			scope, limit := "*", cfg.Limit
+			if l, ok := cfg.Routes[r.Method+" "+v.Route]; ok {
+				scope, limit = r.Method+" "+v.Route, l
+			}
+
+			if err := takeLimit(ctx, w, cfg.Store, scope+"|"+key(ctx, r), limit); err != nil {
+				return err
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// takeLimit counts a request against key, sets the RateLimit-* headers and
+// returns a 429 request error when the limit is exceeded.
+func takeLimit(ctx context.Context, w http.ResponseWriter, store ratelimit.Store, key string, limit ratelimit.Limit) error {
+	if !limit.Enabled() {
+		return nil
+	}
+
+	res, err := store.Take(ctx, key, limit)
+	if err != nil {
+		return errors.Wrap(err, "rate limit")
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", ceilSeconds(res.Reset))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+ceilSeconds(limit.Period))
+	if res.Allowed {
+		return nil
+	}
+
+	w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+	err = errors.Errorf("rate limit of [%v] requests per [%v] exceeded", limit.Requests, limit.Period)
+	return web.NewRequestError(err, http.StatusTooManyRequests)
+}
+
+// ceilSeconds formats d as whole seconds rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,101 @@
+package mid_test
+
+import (
+	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &ratelimit.MemoryStore{Now: func() time.Time { return now }}
+
+	app := newApp(mid.RateLimit(mid.RateLimitConfig{
+		Store: store,
+		Limit: ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Requests: 2, Period: time.Minute},
+		Routes: map[string]ratelimit.Limit{
+			"POST /entities": {Algorithm: ratelimit.TokenBucket, Requests: 1, Period: 10 * time.Second},
+		},
+		Exempt: map[string]bool{"GET /readiness": true},
+	}))
+	app.Handle(http.MethodGet, "/entities", ok)
+	app.Handle(http.MethodPost, "/entities", ok)
+	app.Handle(http.MethodGet, "/readiness", ok)
+
+	tests := []struct {
+		name       string
+		after      time.Duration
+		method     string
+		path       string
+		ip         string
+		status     int
+		limit      string
+		remaining  string
+		reset      string
+		policy     string
+		retryAfter string
+	}{
+		{"first", 0, http.MethodGet, "/entities", "10.0.0.1", http.StatusOK, "2", "1", "60", "2;w=60", ""},
+		{"last", 0, http.MethodGet, "/entities", "10.0.0.1", http.StatusOK, "2", "0", "60", "2;w=60", ""},
+		{"exceeded", 0, http.MethodGet, "/entities", "10.0.0.1", http.StatusTooManyRequests, "2", "0", "60", "2;w=60", "90"},
+		{"other caller", 0, http.MethodGet, "/entities", "10.0.0.2", http.StatusOK, "2", "1", "60", "2;w=60", ""},
+		{"route limit", 0, http.MethodPost, "/entities", "10.0.0.1", http.StatusOK, "1", "0", "10", "1;w=10", ""},
+		{"route limit exceeded", 0, http.MethodPost, "/entities", "10.0.0.1", http.StatusTooManyRequests, "1", "0", "10", "1;w=10", "10"},
+		{"preflight", 0, http.MethodOptions, "/entities", "10.0.0.1", http.StatusNoContent, "", "", "", "", ""},
+		{"exempt", 0, http.MethodGet, "/readiness", "10.0.0.1", http.StatusOK, "", "", "", "", ""},
+		{"window faded", 90 * time.Second, http.MethodGet, "/entities", "10.0.0.1", http.StatusOK, "2", "0", "30", "2;w=60", ""},
+		{"route limit refilled", 0, http.MethodPost, "/entities", "10.0.0.1", http.StatusOK, "1", "0", "10", "1;w=10", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.after)
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.RemoteAddr = tt.ip + ":1234"
+			w := serve(app, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("got status %v, want %v", w.Code, tt.status)
+			}
+			headers := []struct{ name, want string }{
+				{"RateLimit-Limit", tt.limit},
+				{"RateLimit-Remaining", tt.remaining},
+				{"RateLimit-Reset", tt.reset},
+				{"RateLimit-Policy", tt.policy},
+				{"Retry-After", tt.retryAfter},
+			}
+			for _, h := range headers {
+				if got := w.Header().Get(h.name); got != h.want {
+					t.Fatalf("got %v [%v], want [%v]", h.name, got, h.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitByForwardedIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		proxies   int
+		want      string
+	}{
+		{"one proxy", []string{"1.1.1.1, 2.2.2.2"}, 1, "ip:2.2.2.2"},
+		{"two proxies", []string{"1.1.1.1, 2.2.2.2", "3.3.3.3"}, 2, "ip:2.2.2.2"},
+		{"spoofed entries", []string{"9.9.9.9, 1.1.1.1, 2.2.2.2"}, 2, "ip:1.1.1.1"},
+		{"fewer entries than proxies", []string{"2.2.2.2"}, 2, "ip:192.0.2.1"},
+		{"invalid entry", []string{"unknown"}, 1, "ip:192.0.2.1"},
+		{"no header", nil, 1, "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header["X-Forwarded-For"] = tt.forwarded
+			if got := mid.RateLimitByForwardedIP("X-Forwarded-For", tt.proxies)(r.Context(), r); got != tt.want {
+				t.Fatalf("got [%v], want [%v]", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package yourservice
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Set of errors returned by the Service and Store implementations. Callers
+// should test for them with errors.Is as they are usually wrapped with more
+// context.
+var (
+	// ErrNotFound is returned when the requested entity does not exist.
+	ErrNotFound = errors.New("entity not found")
+
+	// ErrConflict is returned when an entity with the same ID already exists
+	// or the stored Version does not match the expected Version.
+	ErrConflict = errors.New("entity conflict")
+
+	// ErrValidation is returned when a request breaks a business rule.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrPrecondition is returned when the Version supplied by the caller is
+	// not the stored Version.
+	ErrPrecondition = errors.New("precondition failed")
+
+	// ErrUnauthorized is returned when the caller may not perform the
+	// operation.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// ValidationError reports a business rule broken by a specific field. It
+// matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+// Error implements the error interface.
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v", v.Field, v.Reason)
+}
+
+// Is reports whether target is ErrValidation.
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
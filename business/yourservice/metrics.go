@@ -0,0 +1,96 @@
+package yourservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// measuredStore wraps a Store recording the count and latency of every call.
+type measuredStore struct {
+	store    Store
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// MeasureStore returns a Store that records store_operations_total and
+// store_operation_duration_seconds in reg, labelled by operation and result.
+func MeasureStore(store Store, reg prometheus.Registerer) Store {
+	m := measuredStore{
+		store: store,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "store_operations_total",
+			Help: "Number of Store operations.",
+		}, []string{"operation", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "store_operation_duration_seconds",
+			Help:    "Latency of Store operations.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+	}
+	reg.MustRegister(m.calls, m.duration)
+	return &m
+}
+
+// observe records an operation that started at start.
+func (m *measuredStore) observe(op string, start time.Time, err error) {
+	result := result(err)
+	m.calls.WithLabelValues(op, result).Inc()
+	m.duration.WithLabelValues(op, result).Observe(time.Since(start).Seconds())
+}
+
+// result classifies an error into a low cardinality label value.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	default:
+		return "error"
+	}
+}
+
+// Create implements Store.
+func (m *measuredStore) Create(ctx context.Context, e Entity) (Entity, error) {
+	start := time.Now()
+	e, err := m.store.Create(ctx, e)
+	m.observe("Create", start, err)
+	return e, err
+}
+
+// Get implements Store.
+func (m *measuredStore) Get(ctx context.Context, id string) (Entity, error) {
+	start := time.Now()
+	e, err := m.store.Get(ctx, id)
+	m.observe("Get", start, err)
+	return e, err
+}
+
+// Update implements Store.
+func (m *measuredStore) Update(ctx context.Context, e Entity) (Entity, error) {
+	start := time.Now()
+	e, err := m.store.Update(ctx, e)
+	m.observe("Update", start, err)
+	return e, err
+}
+
+// Delete implements Store.
+func (m *measuredStore) Delete(ctx context.Context, id string, version int64) error {
+	start := time.Now()
+	err := m.store.Delete(ctx, id, version)
+	m.observe("Delete", start, err)
+	return err
+}
+
+// List implements Store.
+func (m *measuredStore) List(ctx context.Context, filter ListFilter) ([]Entity, error) {
+	start := time.Now()
+	es, err := m.store.List(ctx, filter)
+	m.observe("List", start, err)
+	return es, err
+}
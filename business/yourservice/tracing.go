@@ -0,0 +1,86 @@
+package yourservice
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "dev/yourservice.git/business/yourservice"
+
+// tracedStore wraps a Store creating a span for every call.
+type tracedStore struct {
+	store  Store
+	tracer trace.Tracer
+}
+
+// TraceStore returns a Store that records a child span of the request span
+// for every call to store.
+func TraceStore(store Store) Store {
+	return &tracedStore{
+		store:  store,
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+// start starts a client span for a Store operation.
+func (t *tracedStore) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.operation.name", op))
+	return t.tracer.Start(ctx, "store."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records err on the span and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Create implements Store.
+func (t *tracedStore) Create(ctx context.Context, e Entity) (Entity, error) {
+	ctx, span := t.start(ctx, "Create", attribute.String("entity.id", e.ID))
+	e, err := t.store.Create(ctx, e)
+	end(span, err)
+	return e, err
+}
+
+// Get implements Store.
+func (t *tracedStore) Get(ctx context.Context, id string) (Entity, error) {
+	ctx, span := t.start(ctx, "Get", attribute.String("entity.id", id))
+	e, err := t.store.Get(ctx, id)
+	end(span, err)
+	return e, err
+}
+
+// Update implements Store.
+func (t *tracedStore) Update(ctx context.Context, e Entity) (Entity, error) {
+	ctx, span := t.start(ctx, "Update", attribute.String("entity.id", e.ID))
+	e, err := t.store.Update(ctx, e)
+	end(span, err)
+	return e, err
+}
+
+// Delete implements Store.
+func (t *tracedStore) Delete(ctx context.Context, id string, version int64) error {
+	ctx, span := t.start(ctx, "Delete", attribute.String("entity.id", id))
+	err := t.store.Delete(ctx, id, version)
+	end(span, err)
+	return err
+}
+
+// List implements Store.
+func (t *tracedStore) List(ctx context.Context, filter ListFilter) ([]Entity, error) {
+	ctx, span := t.start(ctx, "List", attribute.Int("list.limit", filter.Limit))
+	es, err := t.store.List(ctx, filter)
+	end(span, err)
+	return es, err
+}
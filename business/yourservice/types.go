@@ -3,10 +3,18 @@ package yourservice
 import (
 	"context"
 	"dev/yourservice.git/business/i"
+	"time"
 )
 
 // Constants
-const ()
+const (
+	// DefaultListLimit is the page size used when a List call does not
+	// specify one.
+	DefaultListLimit = 50
+
+	// MaxListLimit caps the page size a caller may request.
+	MaxListLimit = 500
+)
 
 // Service encapsulates core yourservice functionality
 type Service struct {
@@ -14,7 +22,64 @@ type Service struct {
 	Store Store
 }
 
+// Entity is the record managed by yourservice. Version is incremented on
+// every write and is used for optimistic concurrency control.
+type Entity struct {
+	ID        string    `json:"ID"`
+	Name      string    `json:"Name"`
+	Value     string    `json:"Value"`
+	Version   int64     `json:"Version"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
+
+// NewEntity contains the information needed to create an Entity. ID is
+// optional, the Store generates one when it is empty.
+type NewEntity struct {
+	ID    string `json:"ID,omitempty" validate:"omitempty,max=128,urlsafe"`
+	Name  string `json:"Name" validate:"max=256"`
+	Value string `json:"Value" validate:"required"`
+}
+
+// UpdateEntity contains the information needed to replace an Entity.
+type UpdateEntity struct {
+	Name  string `json:"Name" validate:"max=256"`
+	Value string `json:"Value" validate:"required"`
+}
+
+// PatchEntity contains the fields of an Entity that may be partially
+// updated. Nil fields are left unchanged.
+type PatchEntity struct {
+	Name  *string `json:"Name,omitempty" validate:"omitempty,max=256"`
+	Value *string `json:"Value,omitempty" validate:"omitempty,min=1"`
+}
+
+// ListFilter controls which page of entities is returned by List. Entities
+// are ordered by ID and After is the ID of the last entity of the previous
+// page.
+type ListFilter struct {
+	After string
+	Limit int
+}
+
 // Store encapsulates third-party dependencies
 type Store interface {
-	Create(ctx context.Context) error
+
+	// Create persists a new entity and returns it with its ID and Version
+	// set.
+	Create(ctx context.Context, e Entity) (Entity, error)
+
+	// Get returns the entity with the specified ID.
+	Get(ctx context.Context, id string) (Entity, error)
+
+	// Update replaces the stored entity if its stored Version matches
+	// e.Version and returns it with the incremented Version.
+	Update(ctx context.Context, e Entity) (Entity, error)
+
+	// Delete removes the entity with the specified ID. A non-zero version
+	// must match the stored Version.
+	Delete(ctx context.Context, id string, version int64) error
+
+	// List returns a page of entities ordered by ID.
+	List(ctx context.Context, filter ListFilter) ([]Entity, error)
 }
@@ -0,0 +1,29 @@
+package yourservice
+
+import (
+	"dev/yourservice.git/foundation/web"
+	"strings"
+)
+
+// unsafeIDChars are the characters an ID used as a path param must not hold.
+const unsafeIDChars = "/?#% \t\r\n"
+
+// init registers the validation tags used by the types of this package so
+// they are checked by web.Decode.
+func init() {
+
+	// urlsafe rejects the characters that would break a path param
+	err := web.RegisterValidation("urlsafe", func(fl web.FieldLevel) bool {
+		return !strings.ContainsAny(fl.Field().String(), unsafeIDChars)
+	}, map[string]string{
+		"en":    "{0} must not contain '/', '?', '#', '%' or whitespace",
+		"fr":    "{0} ne doit pas contenir '/', '?', '#', '%' ou d'espaces",
+		"de":    "{0} darf weder '/', '?', '#', '%' noch Leerzeichen enthalten",
+		"es":    "{0} no debe contener '/', '?', '#', '%' ni espacios",
+		"pt":    "{0} não deve conter '/', '?', '#', '%' ou espaços",
+		"pt_BR": "{0} não deve conter '/', '?', '#', '%' ou espaços",
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -2,16 +2,157 @@ package yourservice
 
 import (
 	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
-// Create ...
-func (s *Service) Create(ctx context.Context) error {
+// Create persists a new entity
+func (s *Service) Create(ctx context.Context, ne NewEntity) (Entity, error) {
+
+	// IDs are used as path params so must be URL safe, this also covers IDs
+	// that were not decoded from a body
+	if strings.ContainsAny(ne.ID, unsafeIDChars) {
+		return Entity{}, &ValidationError{Field: "ID", Reason: "must not contain '/', '?', '#', '%' or whitespace"}
+	}
+
+	// Build the entity
+	now := time.Now().UTC()
+	e := Entity{
+		ID:        ne.ID,
+		Name:      ne.Name,
+		Value:     ne.Value,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
 
 	// Create
-	err := s.Store.Create(ctx)
+	e, err := s.Store.Create(ctx, e)
+	if err != nil {
+		return Entity{}, err
+	}
+	return e, nil
+
+}
+
+// Get returns the entity with the specified ID
+func (s *Service) Get(ctx context.Context, id string) (Entity, error) {
+
+	// Get
+	e, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return Entity{}, err
+	}
+	return e, nil
+
+}
+
+// Update replaces the fields of an existing entity. A version of 0 skips the
+// optimistic concurrency check.
+func (s *Service) Update(ctx context.Context, id string, ue UpdateEntity, version int64) (Entity, error) {
+
+	// Get the current entity
+	e, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return Entity{}, err
+	}
+	if version != 0 {
+		e.Version = version
+	}
+
+	// Apply the update
+	e.Name = ue.Name
+	e.Value = ue.Value
+	e.UpdatedAt = time.Now().UTC()
+
+	// Update
+	e, err = s.Store.Update(ctx, e)
+	if err != nil {
+		return Entity{}, precondition(err, version)
+	}
+	return e, nil
+
+}
+
+// Patch updates the supplied fields of an existing entity. A version of 0
+// skips the optimistic concurrency check.
+func (s *Service) Patch(ctx context.Context, id string, pe PatchEntity, version int64) (Entity, error) {
+
+	// Get the current entity
+	e, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return Entity{}, err
+	}
+	if version != 0 {
+		e.Version = version
+	}
+
+	// Apply the patch
+	if pe.Name != nil {
+		e.Name = *pe.Name
+	}
+	if pe.Value != nil {
+		e.Value = *pe.Value
+	}
+	e.UpdatedAt = time.Now().UTC()
+
+	// Update
+	e, err = s.Store.Update(ctx, e)
+	if err != nil {
+		return Entity{}, precondition(err, version)
+	}
+	return e, nil
+
+}
+
+// Delete removes the entity with the specified ID. A version of 0 skips the
+// optimistic concurrency check.
+func (s *Service) Delete(ctx context.Context, id string, version int64) error {
+
+	// Delete
+	err := s.Store.Delete(ctx, id, version)
 	if err != nil {
-		return err
+		return precondition(err, version)
 	}
 	return nil
 
 }
+
+// List returns a page of entities ordered by ID along with the cursor for the
+// next page. The cursor is empty when there are no more entities.
+func (s *Service) List(ctx context.Context, filter ListFilter) ([]Entity, string, error) {
+
+	// Apply limits
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit > MaxListLimit {
+		filter.Limit = MaxListLimit
+	}
+
+	// List one more than asked for to know whether a next page exists
+	limit := filter.Limit
+	filter.Limit++
+	es, err := s.Store.List(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if len(es) > limit {
+		es = es[:limit]
+		next = es[limit-1].ID
+	}
+	return es, next, nil
+
+}
+
+// precondition reports a version conflict as ErrPrecondition when the caller
+// supplied the version. Without a version the conflict was caused by a
+// concurrent write and is returned unchanged.
+func precondition(err error, version int64) error {
+	if version != 0 && errors.Is(err, ErrConflict) {
+		return errors.Wrap(ErrPrecondition, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+// Package auth provides JWT bearer token validation against a set of keys
+// that can be rotated by kid.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// claimsKey is how verified claims are stored/retrieved.
+const claimsKey ctxKey = 1
+
+// Set of signing algorithms accepted by Auth.
+var algorithms = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodHS256.Alg(),
+}
+
+// Claims are the verified claims of a token. Roles and Scope are used for
+// authorization.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"`
+}
+
+// Scopes returns the space separated Scope claim as a slice.
+func (c Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Key is a verification key together with the algorithm it may be used with.
+// Binding the algorithm to the key prevents algorithm confusion attacks such
+// as an RSA public key being used as an HS256 secret.
+type Key struct {
+	Alg string
+	Key interface{}
+}
+
+// KeyLookup returns the key for a kid. An empty kid is passed when the token
+// header has none.
+type KeyLookup interface {
+	Key(kid string) (Key, error)
+}
+
+// Config holds the optional claim checks made by Auth.
+type Config struct {
+	Issuer   string
+	Audience string
+}
+
+// Auth validates JWTs.
+type Auth struct {
+	keys   KeyLookup
+	parser *jwt.Parser
+}
+
+// New constructs an Auth validating tokens with keys from keys.
+func New(keys KeyLookup, cfg Config) *Auth {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &Auth{
+		keys:   keys,
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+// Validate verifies the signature and claims of a compact serialized token.
+func (a *Auth) Validate(token string) (Claims, error) {
+
+	var claims Claims
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := a.keys.Key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.Alg != t.Method.Alg() {
+			return nil, errors.Errorf("key [%v] can not be used with [%v]", kid, t.Method.Alg())
+		}
+		return key.Key, nil
+	}
+
+	if _, err := a.parser.ParseWithClaims(token, &claims, keyFunc); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// SetClaims returns a copy of ctx carrying claims.
+func SetClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// GetClaims returns the claims stored in ctx by SetClaims.
+func GetClaims(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(Claims)
+	return claims, ok
+}
@@ -0,0 +1,122 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"dev/yourservice.git/foundation/auth"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// Keys shared by the tests, generating RSA keys is slow.
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret    = []byte("0123456789abcdef0123456789abcdef")
+)
+
+// sign returns a token for claims signed with key by method, kid is left out
+// of the header when empty.
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return s
+}
+
+// claims returns claims expiring in d.
+func claims(d time.Duration) auth.Claims {
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user",
+			Issuer:    "issuer",
+			Audience:  jwt.ClaimStrings{"api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(d)),
+		},
+		Roles: []string{"admin"},
+		Scope: "entities:read entities:write",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var keys auth.KeySet
+	keys.Add("rsa", auth.Key{Alg: "RS256", Key: &rsaKey.PublicKey})
+	keys.Add("ec", auth.Key{Alg: "ES256", Key: &ecKey.PublicKey})
+	keys.Add("hmac", auth.Key{Alg: "HS256", Key: secret})
+	a := auth.New(&keys, auth.Config{Issuer: "issuer", Audience: "api"})
+
+	noExpiry := claims(time.Hour)
+	noExpiry.ExpiresAt = nil
+	otherIssuer := claims(time.Hour)
+	otherIssuer.Issuer = "other"
+	otherAudience := claims(time.Hour)
+	otherAudience.Audience = jwt.ClaimStrings{"other"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"rsa", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(time.Hour)), nil},
+		{"ec", sign(t, jwt.SigningMethodES256, "ec", ecKey, claims(time.Hour)), nil},
+		{"hmac", sign(t, jwt.SigningMethodHS256, "hmac", secret, claims(time.Hour)), nil},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(-time.Minute)), jwt.ErrTokenExpired},
+		{"no expiry", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, noExpiry), jwt.ErrTokenRequiredClaimMissing},
+		{"other issuer", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherIssuer), jwt.ErrTokenInvalidIssuer},
+		{"other audience", sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherAudience), jwt.ErrTokenInvalidAudience},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "other", rsaKey, claims(time.Hour)), auth.ErrUnknownKey},
+		{"no kid with several keys", sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(time.Hour)), auth.ErrUnknownKey},
+		{"alg of another key", sign(t, jwt.SigningMethodHS256, "rsa", secret, claims(time.Hour)), jwt.ErrTokenUnverifiable},
+		{"alg not accepted", sign(t, jwt.SigningMethodRS512, "rsa", rsaKey, claims(time.Hour)), jwt.ErrTokenSignatureInvalid},
+		{"alg none", sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, claims(time.Hour)), jwt.ErrTokenSignatureInvalid},
+		{"wrong signature", sign(t, jwt.SigningMethodES256, "ec", mustECKey(t), claims(time.Hour)), jwt.ErrTokenSignatureInvalid},
+		{"malformed", "not.a.token", jwt.ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Validate(tt.token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("validating: %v", err)
+				}
+				if got.Subject != "user" || len(got.Roles) != 1 || len(got.Scopes()) != 2 {
+					t.Fatalf("got claims %+v", got)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSingleKeyWithoutKid(t *testing.T) {
+	var keys auth.KeySet
+	keys.Add("only", auth.Key{Alg: "ES256", Key: &ecKey.PublicKey})
+	a := auth.New(&keys, auth.Config{})
+	if _, err := a.Validate(sign(t, jwt.SigningMethodES256, "", ecKey, claims(time.Hour))); err != nil {
+		t.Fatalf("validating: %v", err)
+	}
+}
+
+// mustECKey returns a new P-256 key.
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
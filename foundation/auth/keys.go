@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownKey is returned when no key matches the token's kid.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySet is an in-memory KeyLookup. The zero value is empty.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// Key implements KeyLookup. A token without a kid is only accepted when the
+// set holds exactly one key.
+func (s *KeySet) Key(kid string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	key, ok := s.keys[kid]
+	if !ok {
+		return Key{}, errors.Wrapf(ErrUnknownKey, "kid [%v]", kid)
+	}
+	return key, nil
+}
+
+// Add adds or replaces the key for kid.
+func (s *KeySet) Add(kid string, key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]Key)
+	}
+	s.keys[kid] = key
+}
+
+// Len returns the number of keys in the set.
+func (s *KeySet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys)
+}
+
+// replace swaps all keys at once.
+func (s *KeySet) replace(keys map[string]Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// LoadPEMFolder returns a KeySet of the RSA and EC public keys in the .pem
+// files of dir. The file name without extension is the kid.
+func LoadPEMFolder(dir string) (*KeySet, error) {
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var set KeySet
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePEM(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing [%v]", name)
+		}
+		set.Add(strings.TrimSuffix(filepath.Base(name), ".pem"), key)
+	}
+	return &set, nil
+}
+
+// parsePEM parses a PEM encoded public key.
+func parsePEM(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, err
+	}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return Key{Alg: jwt.SigningMethodRS256.Alg(), Key: pub}, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return Key{}, errors.New("only P-256 EC keys are supported")
+		}
+		return Key{Alg: jwt.SigningMethodES256.Alg(), Key: pub}, nil
+	}
+	return Key{}, errors.Errorf("unsupported public key type [%T]", pub)
+}
+
+// JWKSFile is a KeyLookup backed by a JSON Web Key Set file. The file is
+// reloaded when its modification time changes, checked at most once per
+// interval, so keys can be rotated by writing a new file. A lookup for an
+// unknown kid forces the check. A file that fails to reload is logged and
+// the previous keys stay in use.
+type JWKSFile struct {
+	path     string
+	interval time.Duration
+	set      KeySet
+
+	mu      sync.Mutex
+	checked time.Time
+	modTime time.Time
+	err     error
+}
+
+// NewJWKSFile loads the key set at path.
+func NewJWKSFile(path string, interval time.Duration) (*JWKSFile, error) {
+	f := JWKSFile{
+		path:     path,
+		interval: interval,
+	}
+	f.checked = time.Now()
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Key implements KeyLookup.
+func (f *JWKSFile) Key(kid string) (Key, error) {
+	f.reloadAfter(f.interval)
+	key, err := f.set.Key(kid)
+
+	// The key may have just been rotated in, look again without letting
+	// unknown kids force a reload more than once per second.
+	if errors.Is(err, ErrUnknownKey) && f.reloadAfter(time.Second) {
+		key, err = f.set.Key(kid)
+	}
+	if err != nil {
+		if loadErr := f.Err(); loadErr != nil {
+			return Key{}, errors.Wrapf(err, "key set failed to reload: %v", loadErr)
+		}
+	}
+	return key, err
+}
+
+// Err returns the error of the last reload, nil when it succeeded.
+func (f *JWKSFile) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Len returns the number of keys currently loaded.
+func (f *JWKSFile) Len() int {
+	return f.set.Len()
+}
+
+// reloadAfter reloads the file when it was last checked at least d ago and
+// reports whether it did. A file that fails to load keeps the previous keys
+// in use, the failure is logged when it first occurs.
+func (f *JWKSFile) reloadAfter(d time.Duration) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.checked) < d {
+		return false
+	}
+	f.checked = time.Now()
+	err := f.load()
+	if err != nil && (f.err == nil || f.err.Error() != err.Error()) {
+		slog.Error("reloading jwks file, keeping the previous keys", "file", f.path, "error", err.Error())
+	}
+	f.err = err
+	return true
+}
+
+// load reads the file if it changed since the last load.
+func (f *JWKSFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return errors.Wrapf(err, "parsing [%v]", f.path)
+	}
+	if len(keys) == 0 {
+		return errors.Errorf("[%v] holds no usable signing keys", f.path)
+	}
+	f.set.replace(keys)
+	f.modTime = info.ModTime()
+	return nil
+}
+
+// jwk is a single JSON Web Key. Only the members needed for RSA, EC P-256 and
+// symmetric keys are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set into keys by kid. Keys not meant for
+// signatures and keys of an unsupported type, curve or alg are skipped so
+// one unusable key does not reject the whole set.
+func ParseJWKS(data []byte) (map[string]Key, error) {
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.parse()
+		if err != nil {
+			continue
+		}
+		if k.Alg != "" && k.Alg != key.Alg {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// parse converts the JWK into a key.
+func (k jwk) parse() (Key, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return Key{}, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return Key{}, err
+		}
+		pub := rsa.PublicKey{N: n, E: int(e.Int64())}
+		return Key{Alg: jwt.SigningMethodRS256.Alg(), Key: &pub}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return Key{}, errors.Errorf("unsupported curve [%v]", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return Key{}, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return Key{}, err
+		}
+		pub := ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return Key{}, errors.New("point is not on curve")
+		}
+		return Key{Alg: jwt.SigningMethodES256.Alg(), Key: &pub}, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return Key{}, err
+		}
+		if len(secret) < 32 {
+			return Key{}, errors.New("HS256 secrets must be at least 32 bytes")
+		}
+		return Key{Alg: jwt.SigningMethodHS256.Alg(), Key: secret}, nil
+	}
+	return Key{}, errors.Errorf("unsupported key type [%v]", k.Kty)
+}
+
+// decodeInt decodes a base64url encoded big-endian integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
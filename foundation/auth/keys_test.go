@@ -0,0 +1,192 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"dev/yourservice.git/foundation/auth"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// b64 encodes b as base64url without padding.
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// rsaJWK returns the JWK of an RSA public key.
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+// ecJWK returns the JWK of a P-256 public key.
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(x), "y": b64(y)}
+}
+
+// jwks encodes keys as a JSON Web Key Set.
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// with returns a copy of the JWK k with member set to value.
+func with(k map[string]string, member string, value string) map[string]string {
+	c := make(map[string]string, len(k)+1)
+	for name, v := range k {
+		c[name] = v
+	}
+	c[member] = value
+	return c
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsa, ec := rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)
+	hmac := map[string]string{"kty": "oct", "kid": "hmac", "k": b64(secret)}
+
+	tests := []struct {
+		name string
+		keys []map[string]string
+		want []string
+	}{
+		{"supported keys", []map[string]string{rsa, ec, hmac, with(rsa, "kid", "rsa-sig")}, []string{"ec", "hmac", "rsa", "rsa-sig"}},
+		{"encryption key", []map[string]string{rsa, with(ec, "use", "enc")}, []string{"rsa"}},
+		{"unsupported type", []map[string]string{rsa, {"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AA"}}, []string{"rsa"}},
+		{"unsupported curve", []map[string]string{rsa, with(ec, "crv", "P-384")}, []string{"rsa"}},
+		{"unsupported alg", []map[string]string{with(rsa, "alg", "RS512"), ec}, []string{"ec"}},
+		{"matching alg", []map[string]string{with(rsa, "alg", "RS256")}, []string{"rsa"}},
+		{"short secret", []map[string]string{rsa, with(hmac, "k", b64([]byte("short")))}, []string{"rsa"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := auth.ParseJWKS(jwks(t, tt.keys...))
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+			var got []string
+			for kid := range keys {
+				got = append(got, kid)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got kids %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got kids %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := auth.ParseJWKS([]byte("{")); err == nil {
+		t.Fatal("parsed invalid JSON")
+	}
+}
+
+// writeFile writes data to name with a modification time of mod.
+func writeFile(t *testing.T, name string, data []byte, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWKSFileRotation(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "jwks.json")
+	now := time.Now()
+	writeFile(t, name, jwks(t, rsaJWK("old", &rsaKey.PublicKey)), now.Add(-time.Hour))
+
+	// A zero interval checks the file on every lookup
+	keys, err := auth.NewJWKSFile(name, 0)
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	a := auth.New(keys, auth.Config{})
+	oldToken := sign(t, jwt.SigningMethodRS256, "old", rsaKey, claims(time.Hour))
+	newToken := sign(t, jwt.SigningMethodES256, "new", ecKey, claims(time.Hour))
+	if _, err := a.Validate(oldToken); err != nil {
+		t.Fatalf("validating with the old key: %v", err)
+	}
+	if _, err := a.Validate(newToken); !errors.Is(err, auth.ErrUnknownKey) {
+		t.Fatalf("got %v before rotation, want ErrUnknownKey", err)
+	}
+
+	// Rotate the new key in and the old one out
+	writeFile(t, name, jwks(t, ecJWK("new", &ecKey.PublicKey)), now.Add(-time.Minute))
+	if _, err := a.Validate(newToken); err != nil {
+		t.Fatalf("validating with the rotated key: %v", err)
+	}
+	if _, err := a.Validate(oldToken); !errors.Is(err, auth.ErrUnknownKey) {
+		t.Fatalf("got %v after rotation, want ErrUnknownKey", err)
+	}
+	if keys.Err() != nil {
+		t.Fatalf("reload failed: %v", keys.Err())
+	}
+
+	// A broken file keeps the current keys and is reported
+	writeFile(t, name, []byte("{"), now)
+	if _, err := a.Validate(newToken); err != nil {
+		t.Fatalf("validating after a failed reload: %v", err)
+	}
+	if keys.Err() == nil {
+		t.Fatal("failed reload not reported")
+	}
+	if _, err := a.Validate(oldToken); !errors.Is(err, auth.ErrUnknownKey) || err.Error() == auth.ErrUnknownKey.Error() {
+		t.Fatalf("got %v, want ErrUnknownKey mentioning the reload failure", err)
+	}
+}
+
+func TestNewJWKSFileWithoutKeys(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "jwks.json")
+	writeFile(t, name, jwks(t, map[string]string{"kty": "OKP", "kid": "ed"}), time.Now())
+	if _, err := auth.NewJWKSFile(name, time.Minute); err == nil {
+		t.Fatal("loaded a key set without usable keys")
+	}
+}
+
+func TestLoadPEMFolder(t *testing.T) {
+	dir := t.TempDir()
+	for kid, pub := range map[string]interface{}{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey} {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		writeFile(t, filepath.Join(dir, kid+".pem"), data, time.Now())
+	}
+
+	keys, err := auth.LoadPEMFolder(dir)
+	if err != nil {
+		t.Fatalf("loading: %v", err)
+	}
+	a := auth.New(keys, auth.Config{})
+	for _, token := range []string{
+		sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(time.Hour)),
+		sign(t, jwt.SigningMethodES256, "ec", ecKey, claims(time.Hour)),
+	} {
+		if _, err := a.Validate(token); err != nil {
+			t.Fatalf("validating: %v", err)
+		}
+	}
+}
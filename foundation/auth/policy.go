@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForbidden is returned when verified claims do not grant access.
+var ErrForbidden = errors.New("forbidden")
+
+// Rule lists what a caller needs to access a route. The caller must have at
+// least one of Roles, when any are listed, and all of Scopes.
+type Rule struct {
+	Method string   `json:"method"`
+	Path   string   `json:"path"`
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Policy maps routes to the Rule protecting them. Routes are identified by
+// method and route pattern, for example GET /entities/:id.
+type Policy struct {
+	// DefaultDeny rejects routes that have no rule instead of allowing
+	// them.
+	DefaultDeny bool   `json:"defaultDeny"`
+	Rules       []Rule `json:"rules"`
+
+	index map[string]Rule
+}
+
+// LoadPolicy reads a JSON policy file.
+func LoadPolicy(path string) (*Policy, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.Wrapf(err, "parsing policy [%v]", path)
+	}
+
+	p.index = make(map[string]Rule, len(p.Rules))
+	for _, rule := range p.Rules {
+		key := rule.Method + " " + rule.Path
+		if _, exists := p.index[key]; exists {
+			return nil, errors.Errorf("policy [%v] has more than one rule for [%v]", path, key)
+		}
+		p.index[key] = rule
+	}
+	return &p, nil
+}
+
+// Check returns ErrForbidden when claims do not satisfy the rule for the
+// route.
+func (p *Policy) Check(method string, route string, claims Claims) error {
+	rule, ok := p.index[method+" "+route]
+	if !ok {
+		if p.DefaultDeny {
+			return errors.Wrapf(ErrForbidden, "no policy for [%v %v]", method, route)
+		}
+		return nil
+	}
+	if len(rule.Roles) > 0 && !claims.HasAnyRole(rule.Roles...) {
+		return errors.Wrapf(ErrForbidden, "requires one of roles %v", rule.Roles)
+	}
+	if !claims.HasScopes(rule.Scopes...) {
+		return errors.Wrapf(ErrForbidden, "requires scopes %v", rule.Scopes)
+	}
+	return nil
+}
+
+// HasAnyRole reports whether the claims contain at least one of roles.
+func (c Claims) HasAnyRole(roles ...string) bool {
+	for _, have := range c.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasScopes reports whether the claims contain all of scopes.
+func (c Claims) HasScopes(scopes ...string) bool {
+	have := make(map[string]bool)
+	for _, scope := range c.Scopes() {
+		have[scope] = true
+	}
+	for _, want := range scopes {
+		if !have[want] {
+			return false
+		}
+	}
+	return true
+}
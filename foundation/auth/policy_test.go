@@ -0,0 +1,80 @@
+package auth_test
+
+import (
+	"dev/yourservice.git/foundation/auth"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// loadPolicy writes data to a policy file and loads it.
+func loadPolicy(t *testing.T, data string) *auth.Policy {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := auth.LoadPolicy(name)
+	if err != nil {
+		t.Fatalf("loading policy: %v", err)
+	}
+	return p
+}
+
+func TestPolicyCheck(t *testing.T) {
+	const rules = `"rules": [
+		{"method": "GET", "path": "/entities", "scopes": ["entities:read"]},
+		{"method": "POST", "path": "/entities", "roles": ["admin", "editor"], "scopes": ["entities:read", "entities:write"]},
+		{"method": "DELETE", "path": "/entities/:id", "roles": ["admin"]},
+		{"method": "GET", "path": "/status"}
+	]`
+	allow := loadPolicy(t, `{`+rules+`}`)
+	deny := loadPolicy(t, `{"defaultDeny": true, `+rules+`}`)
+
+	reader := auth.Claims{Roles: []string{"viewer"}, Scope: "entities:read"}
+	editor := auth.Claims{Roles: []string{"editor"}, Scope: "entities:write entities:read"}
+	admin := auth.Claims{Roles: []string{"viewer", "admin"}}
+
+	tests := []struct {
+		name      string
+		policy    *auth.Policy
+		method    string
+		route     string
+		claims    auth.Claims
+		forbidden bool
+	}{
+		{"scope held", allow, "GET", "/entities", reader, false},
+		{"scope missing", allow, "GET", "/entities", admin, true},
+		{"role and all scopes held", allow, "POST", "/entities", editor, false},
+		{"one of several scopes held", allow, "POST", "/entities", auth.Claims{Roles: []string{"admin"}, Scope: "entities:write"}, true},
+		{"no listed role", allow, "POST", "/entities", auth.Claims{Roles: []string{"viewer"}, Scope: "entities:read entities:write"}, true},
+		{"role among several", allow, "DELETE", "/entities/:id", admin, false},
+		{"role missing", allow, "DELETE", "/entities/:id", editor, true},
+		{"route pattern not path", allow, "DELETE", "/entities/a", editor, false},
+		{"rule without requirements", allow, "GET", "/status", auth.Claims{}, false},
+		{"other method allowed", allow, "PUT", "/entities", auth.Claims{}, false},
+		{"other method denied", deny, "PUT", "/entities", admin, true},
+		{"rule under default deny", deny, "GET", "/entities", reader, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.method, tt.route, tt.claims)
+			if tt.forbidden != errors.Is(err, auth.ErrForbidden) || (!tt.forbidden && err != nil) {
+				t.Fatalf("got %v, want forbidden [%v]", err, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyDuplicateRule(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "policy.json")
+	data := `{"rules": [{"method": "GET", "path": "/entities"}, {"method": "GET", "path": "/entities", "roles": ["admin"]}]}`
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.LoadPolicy(name); err == nil {
+		t.Fatal("loaded a policy with two rules for one route")
+	}
+}
@@ -0,0 +1,34 @@
+// Package idempotency stores the responses of requests made with an
+// Idempotency-Key so retries can be answered without repeating the request.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Record is the state of one idempotency key. Until Done it only reserves the
+// key for the request in flight.
+type Record struct {
+	Fingerprint string
+	Done        bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// Store holds the records of every key. Records expire after the TTL given
+// when they were reserved or completed.
+type Store interface {
+
+	// Reserve stores an in flight record for key unless the key is already
+	// used, in which case the existing record is returned with false.
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error)
+
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+
+	// Release removes the reservation of key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how often the MemoryStore drops expired records.
+const sweepEvery = time.Minute
+
+// entry is a stored record and when it expires.
+type entry struct {
+	rec     Record
+	expires time.Time
+}
+
+// MemoryStore is a Store local to this process. The zero value is ready to
+// use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	swept   time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Reserve implements Store.
+func (m *MemoryStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	if e, ok := m.entries[key]; ok && now.Before(e.expires) {
+		return e.rec, false, nil
+	}
+	rec := Record{Fingerprint: fingerprint}
+	m.entries[key] = entry{rec: rec, expires: now.Add(ttl)}
+	return rec, true, nil
+}
+
+// Complete implements Store.
+func (m *MemoryStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec.Done = true
+	m.entries[key] = entry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release implements Store.
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep drops expired records and creates the map on first use.
+func (m *MemoryStore) sweep(now time.Time) {
+	if m.entries == nil {
+		m.entries = make(map[string]entry)
+	}
+	if now.Sub(m.swept) < sweepEvery {
+		return
+	}
+	m.swept = now
+	for key, e := range m.entries {
+		if now.After(e.expires) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package logger provides the structured logger used by the service. Logs are
+// written as JSON understood by Cloud Logging or as human readable text for
+// local development.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New constructs a structured logger writing to w. When json is true the
+// output uses the field names Cloud Logging expects for severity and message.
+func New(w io.Writer, level slog.Leveler, json bool) *slog.Logger {
+
+	if !json {
+		return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+
+	// Rename the standard keys to the ones Cloud Logging recognises.
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) > 0 {
+			return a
+		}
+		switch a.Key {
+		case slog.MessageKey:
+			a.Key = "message"
+		case slog.LevelKey:
+			a.Key = "severity"
+			a.Value = slog.StringValue(severity(a.Value.Any().(slog.Level)))
+		}
+		return a
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replace,
+	}))
+}
+
+// ParseLevel converts a level name such as debug, info, warn or error into a
+// slog.Level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.ToUpper(name)))
+	return level, err
+}
+
+// severity maps a slog.Level to a Cloud Logging LogSeverity.
+func severity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
+
+// Printf adapts a structured logger to the Println/Printf style i.Logger
+// interface so existing callers keep working. Every line is logged as the
+// message at Level.
+type Printf struct {
+	Log   *slog.Logger
+	Level slog.Level
+}
+
+// NewPrintf returns a Printf adapter logging at info level.
+func NewPrintf(log *slog.Logger) *Printf {
+	return &Printf{Log: log, Level: slog.LevelInfo}
+}
+
+// Println logs the operands formatted as by fmt.Sprintln.
+func (p *Printf) Println(v ...interface{}) {
+	p.Log.Log(context.Background(), p.Level, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Printf logs the operands formatted as by fmt.Sprintf.
+func (p *Printf) Printf(format string, v ...interface{}) {
+	p.Log.Log(context.Background(), p.Level, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
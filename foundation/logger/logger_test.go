@@ -0,0 +1,83 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"dev/yourservice.git/foundation/logger"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewJSON(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		severity string
+	}{
+		{slog.LevelDebug, "DEBUG"},
+		{slog.LevelInfo, "INFO"},
+		{slog.LevelWarn, "WARNING"},
+		{slog.LevelError, "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logger.New(&buf, slog.LevelDebug, true)
+			log.Log(context.Background(), tt.level, "hello", "trace_id", "abc")
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("decoding [%s]: %v", buf.Bytes(), err)
+			}
+			if entry["severity"] != tt.severity || entry["message"] != "hello" || entry["trace_id"] != "abc" {
+				t.Fatalf("logged %v", entry)
+			}
+		})
+	}
+}
+
+func TestNewLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, slog.LevelWarn, false)
+	log.Info("dropped")
+	log.Warn("kept")
+	if out := buf.String(); strings.Contains(out, "dropped") || !strings.Contains(out, "kept") {
+		t.Fatalf("logged [%v]", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"WARN", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := logger.ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr || (err == nil && level != tt.want) {
+				t.Fatalf("got %v %v, want %v error [%v]", level, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	p := logger.NewPrintf(logger.New(&buf, slog.LevelInfo, true))
+	p.Printf("created [%v]\n", "a")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding [%s]: %v", buf.Bytes(), err)
+	}
+	if entry["message"] != "created [a]" || entry["severity"] != "INFO" {
+		t.Fatalf("logged %v", entry)
+	}
+}
@@ -0,0 +1,351 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// uuidPattern matches the textual form of a UUID.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// patternCache holds the compiled pattern keywords, a pattern that does not
+// compile is stored as nil and not checked.
+var patternCache sync.Map
+
+// check appends a Violation to out for every way value, decoded from JSON
+// with numbers as json.Number, does not match s. field is the path of the
+// value, empty for the body itself.
+func (v *Validator) check(s *Schema, value interface{}, field string, out *[]Violation) {
+	s = v.schema(s)
+	if s == nil {
+		return
+	}
+
+	// Combined schemas
+	for _, sub := range s.AllOf {
+		v.check(sub, value, field, out)
+	}
+	if len(s.AnyOf) > 0 && v.matches(s.AnyOf, value) == 0 {
+		report(out, field, "%s must match at least one of the allowed schemas")
+	}
+	if len(s.OneOf) > 0 && v.matches(s.OneOf, value) != 1 {
+		report(out, field, "%s must match exactly one of the allowed schemas")
+	}
+	if s.Not != nil && v.matches([]*Schema{s.Not}, value) == 1 {
+		report(out, field, "%s must not match the disallowed schema")
+	}
+
+	// The type decides which keywords apply
+	typ := jsonType(value)
+	if !typeAllowed(s, typ) {
+		report(out, field, "%s must be of type %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = fmt.Sprint(e)
+			if e == nil {
+				values[i] = "null"
+			}
+		}
+		report(out, field, "%s must be one of [%s]", strings.Join(values, " "))
+	}
+
+	switch value := value.(type) {
+	case string:
+		checkString(s, value, field, out)
+	case json.Number:
+		f, _ := value.Float64()
+		checkNumber(s, f, field, out)
+	case []interface{}:
+		v.checkArray(s, value, field, out)
+	case map[string]interface{}:
+		v.checkObject(s, value, field, out)
+	}
+}
+
+// matches returns how many of schemas value matches.
+func (v *Validator) matches(schemas []*Schema, value interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		var out []Violation
+		v.check(sub, value, "", &out)
+		if len(out) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// checkString applies the string keywords of s.
+func checkString(s *Schema, value string, field string, out *[]Violation) {
+	n := utf8.RuneCountInString(value)
+	if s.MinLength != nil && n < *s.MinLength {
+		report(out, field, "%s must be at least %d characters in length", *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		report(out, field, "%s must be a maximum of %d characters in length", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		if re := compilePattern(s.Pattern); re != nil && !re.MatchString(value) {
+			report(out, field, "%s must match the pattern %s", s.Pattern)
+		}
+	}
+	if !validFormat(s.Format, value) {
+		report(out, field, "%s must be a valid %s", s.Format)
+	}
+}
+
+// checkNumber applies the number keywords of s.
+func checkNumber(s *Schema, value float64, field string, out *[]Violation) {
+	if s.Minimum != nil && value < *s.Minimum {
+		report(out, field, "%s must be %v or greater", *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		report(out, field, "%s must be %v or less", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		report(out, field, "%s must be greater than %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		report(out, field, "%s must be less than %v", *s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := value / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			report(out, field, "%s must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+	switch s.Format {
+	case "int32":
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			report(out, field, "%s must be a valid %s", s.Format)
+		}
+	}
+}
+
+// checkArray applies the array keywords of s and checks every item.
+func (v *Validator) checkArray(s *Schema, value []interface{}, field string, out *[]Violation) {
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		report(out, field, "%s must contain at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		report(out, field, "%s must contain at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if reflect.DeepEqual(normalize(value[i]), normalize(value[j])) {
+					report(out, field, "%s must contain unique items")
+					i = len(value)
+					break
+				}
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range value {
+			v.check(s.Items, item, fmt.Sprintf("%s[%d]", field, i), out)
+		}
+	}
+}
+
+// checkObject applies the object keywords of s and checks every property.
+// Properties are checked in name order so the violations are stable.
+func (v *Validator) checkObject(s *Schema, value map[string]interface{}, field string, out *[]Violation) {
+	if s.MinProperties != nil && len(value) < *s.MinProperties {
+		report(out, field, "%s must contain at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(value) > *s.MaxProperties {
+		report(out, field, "%s must contain at most %d properties", *s.MaxProperties)
+	}
+	for _, name := range s.Required {
+		if _, exists := value[name]; !exists {
+			report(out, child(field, name), "%s is a required field")
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prop, exists := s.Properties[name]; exists {
+			v.check(prop, value[name], child(field, name), out)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if isFalse(s.AdditionalProperties) {
+			report(out, child(field, name), "%s is not allowed")
+			continue
+		}
+		v.check(s.AdditionalProperties, value[name], child(field, name), out)
+	}
+}
+
+// report appends a Violation of field, the first %s of format is replaced
+// by the name of the field and the rest by args. The body itself is reported
+// as the field body.
+func report(out *[]Violation, field string, format string, args ...interface{}) {
+	if field == "" {
+		field = "body"
+	}
+	*out = append(*out, Violation{
+		Field:   field,
+		Message: fmt.Sprintf(format, append([]interface{}{leaf(field)}, args...)...),
+	})
+}
+
+// child returns the path of the property name of field.
+func child(field string, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// leaf returns the name of the last property in field.
+func leaf(field string) string {
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		return field[i+1:]
+	}
+	return field
+}
+
+// jsonType returns the JSON Schema type of a decoded value, whole numbers
+// are integers.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		f, err := value.Float64()
+		if err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// typeAllowed reports whether s allows values of typ.
+func typeAllowed(s *Schema, typ string) bool {
+	switch {
+	case len(s.Type) == 0:
+		return true
+	case typ == "null" && s.Nullable:
+		return true
+	case typ == "integer" && s.Type.Has("number"):
+		return true
+	}
+	return s.Type.Has(typ)
+}
+
+// inEnum reports whether value is one of enum.
+func inEnum(enum []interface{}, value interface{}) bool {
+	value = normalize(value)
+	for _, e := range enum {
+		if reflect.DeepEqual(normalize(e), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts the numbers in value to float64 so values decoded
+// differently compare equal.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = normalize(item)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[k] = normalize(item)
+		}
+		return m
+	}
+	return value
+}
+
+// isFalse reports whether s is the schema false, which matches nothing.
+func isFalse(s *Schema) bool {
+	return s.Not != nil && reflect.DeepEqual(*s.Not, Schema{})
+}
+
+// compilePattern returns the compiled pattern keyword.
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	patternCache.Store(pattern, re)
+	return re
+}
+
+// validFormat reports whether value has the string format. Unknown formats
+// are not checked.
+func validFormat(format string, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	case "byte":
+		_, err := base64.StdEncoding.DecodeString(value)
+		return err == nil
+	}
+	return true
+}
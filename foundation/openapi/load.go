@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the OpenAPI document at path. Files ending in .yaml or .yml are
+// read as YAML and anything else as JSON.
+func Load(path string) (*Document, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading openapi document")
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errors.Wrapf(err, "parsing openapi document [%v]", path)
+		}
+		data, err = json.Marshal(stringKeys(v))
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting openapi document [%v]", path)
+		}
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrapf(err, "parsing openapi document [%v]", path)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, errors.Errorf("openapi document [%v] has unsupported version [%v]", path, doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// stringKeys converts the maps decoded from YAML to maps with string keys
+// so they can be encoded as JSON. YAML allows keys such as the unquoted
+// response status 200.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = stringKeys(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = stringKeys(e)
+		}
+		return v
+	}
+	return v
+}
@@ -0,0 +1,276 @@
+// Package openapi provides the OpenAPI 3.1 document model and derives JSON
+// schemas from Go types.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents built by this package.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document. Only the parts used by services built on
+// this module are modelled.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is served from.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the objects referenced from operations.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty"`
+}
+
+// PathItem holds the operations of a path template. Parameters apply to
+// every operation unless the operation overrides them.
+type PathItem struct {
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty"`
+
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation returns the operation of method, nil when there is none.
+func (p *PathItem) Operation(method string) *Operation {
+	if op := p.operation(method); op != nil {
+		return *op
+	}
+	return nil
+}
+
+// SetOperation sets the operation of method, unknown methods are ignored.
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	if o := p.operation(method); o != nil {
+		*o = op
+	}
+}
+
+// operation returns the field holding the operation of method.
+func (p *PathItem) operation(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	case "OPTIONS":
+		return &p.Options
+	case "HEAD":
+		return &p.Head
+	case "PATCH":
+		return &p.Patch
+	case "TRACE":
+		return &p.Trace
+	}
+	return nil
+}
+
+// Operation describes one method of a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path, query, header or cookie parameter of an operation.
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of an operation per media type.
+type RequestBody struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Response describes a response of an operation per media type.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is the JSON Schema subset used to describe bodies and parameters.
+type Schema struct {
+	Ref         string `json:"$ref,omitempty"`
+	Type        Types  `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Nullable is the OpenAPI 3.0 form of allowing null.
+	Nullable bool `json:"nullable,omitempty"`
+
+	Enum    []interface{} `json:"enum,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+
+	Items       *Schema `json:"items,omitempty"`
+	MinItems    *int    `json:"minItems,omitempty"`
+	MaxItems    *int    `json:"maxItems,omitempty"`
+	UniqueItems bool    `json:"uniqueItems,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+}
+
+// UnmarshalJSON reads a schema, including the boolean schemas true and false
+// and the OpenAPI 3.0 boolean exclusiveMinimum and exclusiveMaximum.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}}
+		return nil
+	}
+
+	type schema Schema
+	aux := struct {
+		*schema
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum"`
+	}{schema: (*schema)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	s.ExclusiveMinimum, s.Minimum, err = exclusive(aux.ExclusiveMinimum, s.Minimum)
+	if err != nil {
+		return err
+	}
+	s.ExclusiveMaximum, s.Maximum, err = exclusive(aux.ExclusiveMaximum, s.Maximum)
+	return err
+}
+
+// exclusive returns the exclusive and inclusive bound of an exclusiveMinimum
+// or exclusiveMaximum keyword, a true boolean makes the inclusive bound
+// exclusive.
+func exclusive(raw json.RawMessage, inclusive *float64) (*float64, *float64, error) {
+	switch string(raw) {
+	case "", "null", "false":
+		return nil, inclusive, nil
+	case "true":
+		return inclusive, nil, nil
+	}
+	var n float64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return nil, nil, err
+	}
+	return &n, inclusive, nil
+}
+
+// RefName returns the name of the component schema referenced by s, or ""
+// when s is not a local reference.
+func (s *Schema) RefName() string {
+	const prefix = "#/components/schemas/"
+	if !strings.HasPrefix(s.Ref, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(s.Ref, prefix)
+}
+
+// Types is the type keyword of a Schema. OpenAPI 3.1 allows a single type or
+// a list of them, for example ["string", "null"].
+type Types []string
+
+// Has reports whether t allows typ. An empty Types allows any type.
+func (t Types) Has(typ string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, v := range t {
+		if v == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON writes a single type as a string.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON reads a single type or a list of them.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
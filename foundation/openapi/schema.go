@@ -0,0 +1,367 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeArgPath matches the package path of a type argument in the name of an
+// instantiated generic type.
+var typeArgPath = regexp.MustCompile(`[^\[\],]*\.`)
+
+// formats maps validate tags to the string format they imply.
+var formats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ip":       "ip",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+// patterns maps validate tags to the string pattern they imply.
+var patterns = map[string]string{
+	"alpha":    "^[a-zA-Z]+$",
+	"alphanum": "^[a-zA-Z0-9]+$",
+	"numeric":  "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+}
+
+// Generator derives schemas from Go types. Fields are named by their json
+// tags and constrained by their validate tags. Named struct types are added
+// to the components of the document and referenced.
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+	types map[string]reflect.Type
+}
+
+// NewGenerator returns a Generator adding component schemas to doc.
+func NewGenerator(doc *Document) *Generator {
+	if doc.Components.Schemas == nil {
+		doc.Components.Schemas = make(map[string]*Schema)
+	}
+	return &Generator{
+		doc:   doc,
+		names: make(map[reflect.Type]string),
+		types: make(map[string]reflect.Type),
+	}
+}
+
+// Schema returns the schema of the type of v, typically its zero value.
+func (g *Generator) Schema(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Parameters returns a parameter located in, for example "query", for every
+// field of the struct v. The name is taken from the tag named after in, then
+// the json tag, then the field name.
+func (g *Generator) Parameters(v interface{}, in string) []*Parameter {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tagName(f.Tag.Get(in))
+		if name == "" {
+			name = tagName(f.Tag.Get("json"))
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := g.schema(f.Type)
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: constrain(s, f.Type, f.Tag.Get("validate")),
+			Schema:   s,
+		})
+	}
+	return params
+}
+
+// schema returns the schema of t.
+func (g *Generator) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types with their own JSON encoding
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		return &Schema{}
+	case t.Implements(textType) || reflect.PtrTo(t).Implements(textType):
+		return &Schema{Type: Types{"string"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: Types{"integer"}, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: Types{"integer"}, Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: Types{"integer"}, Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: Types{"number"}, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: Types{"number"}, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Types{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: Types{"array"}, Items: g.schema(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &Schema{Type: Types{"array"}, Items: g.schema(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}, AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+
+	// Interfaces and anything else accept any value
+	return &Schema{}
+}
+
+// ref returns a reference to the component schema of the named struct t,
+// adding it on first use.
+func (g *Generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.name(t)
+		g.names[t] = name
+		g.types[name] = t
+
+		// Add the schema before filling it so recursive types refer to it
+		s := &Schema{}
+		g.doc.Components.Schemas[name] = s
+		*s = *g.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// name returns a component name for t not used by another type.
+func (g *Generator) name(t reflect.Type) string {
+	base := typeArgPath.ReplaceAllString(t.Name(), "")
+	base = strings.NewReplacer("[", "", "]", "", ",", "").Replace(base)
+
+	candidates := []string{base, path.Base(t.PkgPath()) + "." + base}
+	for _, name := range candidates {
+		if _, taken := g.types[name]; !taken {
+			return name
+		}
+	}
+	for i := 2; ; i++ {
+		name := candidates[1] + strconv.Itoa(i)
+		if _, taken := g.types[name]; !taken {
+			return name
+		}
+	}
+}
+
+// object returns the object schema of the struct t.
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+// fields adds the fields of the struct t to s, the fields of embedded
+// structs without a json name are promoted as encoding/json does.
+func (g *Generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := tagName(f.Tag.Get("json"))
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, s)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.schema(f.Type)
+		if constrain(fs, f.Type, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// constrain applies the validate tag of a field of type t to its schema and
+// reports whether the field is required. Tags after dive apply to the items.
+func constrain(s *Schema, t reflect.Type, tag string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	required := false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param := rule, ""
+		if j := strings.Index(rule, "="); j >= 0 {
+			name, param = rule[:j], rule[j+1:]
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if s.Items != nil {
+				constrain(s.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			} else if s.AdditionalProperties != nil {
+				constrain(s.AdditionalProperties, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			bound(s, t, name, param)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(t, v))
+			}
+		default:
+			if format, ok := formats[name]; ok {
+				s.Format = format
+			}
+			if pattern, ok := patterns[name]; ok {
+				s.Pattern = pattern
+			}
+		}
+	}
+	return required
+}
+
+// bound applies a min, max, len, gt, gte, lt or lte rule with param to s. The
+// rule limits the length of strings, the items of slices and arrays and the
+// value of numbers.
+func bound(s *Schema, t reflect.Type, rule string, param string) {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		min, max := &s.MinLength, &s.MaxLength
+		if t.Kind() != reflect.String {
+			min, max = &s.MinItems, &s.MaxItems
+		}
+		switch rule {
+		case "min", "gte":
+			*min = &n
+		case "max", "lte":
+			*max = &n
+		case "len":
+			*min, *max = &n, &n
+		case "gt":
+			n++
+			*min = &n
+		case "lt":
+			n--
+			*max = &n
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		switch rule {
+		case "min", "gte":
+			s.Minimum = &n
+		case "max", "lte":
+			s.Maximum = &n
+		case "len":
+			s.Minimum, s.Maximum = &n, &n
+		case "gt":
+			s.ExclusiveMinimum = &n
+		case "lt":
+			s.ExclusiveMaximum = &n
+		}
+	}
+}
+
+// enumValue returns the oneof value v as a number for numeric fields.
+func enumValue(t reflect.Type, v string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// tagName returns the name of a struct tag without its options.
+func tagName(tag string) string {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// float returns a pointer to f.
+func float(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,145 @@
+package openapi_test
+
+import (
+	"dev/yourservice.git/foundation/openapi"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type constrained struct {
+	Name     string         `json:"name" validate:"required,min=1,max=10"`
+	Code     string         `json:"code" validate:"required,len=3,alpha"`
+	Age      int            `json:"age" validate:"min=18,max=130"`
+	Score    float64        `json:"score" validate:"gt=0,lt=1"`
+	Level    int            `json:"level" validate:"oneof=1 2 3"`
+	Kind     string         `json:"kind" validate:"oneof=small large"`
+	Tags     []string       `json:"tags" validate:"max=3,dive,oneof=a b"`
+	Counts   map[string]int `json:"counts" validate:"dive,min=1"`
+	Email    *string        `json:"email,omitempty" validate:"omitempty,email"`
+	Homepage string         `json:"homepage" validate:"omitempty,url"`
+	Ignored  string         `json:"-" validate:"required"`
+	private  string
+}
+
+type entity struct {
+	ID string `json:"id"`
+}
+
+type page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+type audit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type base struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type embedding struct {
+	base
+	*audit
+	Parent base   `json:"parent"`
+	Name   string `json:"name"`
+}
+
+// generate returns the document holding the schemas of vs.
+func generate(vs ...interface{}) (*openapi.Document, []*openapi.Schema) {
+	doc := openapi.Document{}
+	g := openapi.NewGenerator(&doc)
+	var schemas []*openapi.Schema
+	for _, v := range vs {
+		schemas = append(schemas, g.Schema(v))
+	}
+	return &doc, schemas
+}
+
+// equalJSON fails the test unless s encodes to want.
+func equalJSON(t *testing.T, name string, s interface{}, want string) {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%v is %s\nwant %s", name, data, want)
+	}
+}
+
+func TestGeneratorConstraints(t *testing.T) {
+	doc, _ := generate(constrained{})
+	s := doc.Components.Schemas["constrained"]
+	if s == nil {
+		t.Fatalf("constrained not added to the components: %v", doc.Components.Schemas)
+	}
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"name", `{"type":"string","minLength":1,"maxLength":10}`},
+		{"code", `{"type":"string","pattern":"^[a-zA-Z]+$","minLength":3,"maxLength":3}`},
+		{"age", `{"type":"integer","format":"int64","minimum":18,"maximum":130}`},
+		{"score", `{"type":"number","format":"double","exclusiveMinimum":0,"exclusiveMaximum":1}`},
+		{"level", `{"type":"integer","format":"int64","enum":[1,2,3]}`},
+		{"kind", `{"type":"string","enum":["small","large"]}`},
+		{"tags", `{"type":"array","items":{"type":"string","enum":["a","b"]},"maxItems":3}`},
+		{"counts", `{"type":"object","additionalProperties":{"type":"integer","format":"int64","minimum":1}}`},
+		{"email", `{"type":"string","format":"email"}`},
+		{"homepage", `{"type":"string","format":"uri"}`},
+	}
+	for _, tt := range tests {
+		equalJSON(t, tt.field, s.Properties[tt.field], tt.want)
+	}
+
+	if len(s.Properties) != len(tests) {
+		t.Errorf("got %v properties, want %v", len(s.Properties), len(tests))
+	}
+	if want := []string{"name", "code"}; !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("required %v, want %v", s.Required, want)
+	}
+}
+
+func TestGeneratorGenericNames(t *testing.T) {
+	doc, schemas := generate(page[entity]{}, page[int]{})
+
+	tests := []struct {
+		name  string
+		ref   string
+		items string
+	}{
+		{"pageentity", "#/components/schemas/pageentity", `{"type":"array","items":{"$ref":"#/components/schemas/entity"}}`},
+		{"pageint", "#/components/schemas/pageint", `{"type":"array","items":{"type":"integer","format":"int64"}}`},
+	}
+	for i, tt := range tests {
+		if schemas[i].Ref != tt.ref {
+			t.Errorf("got ref [%v], want [%v]", schemas[i].Ref, tt.ref)
+		}
+		s := doc.Components.Schemas[tt.name]
+		if s == nil {
+			t.Fatalf("[%v] not in the components: %v", tt.name, doc.Components.Schemas)
+		}
+		equalJSON(t, tt.name+" items", s.Properties["items"], tt.items)
+	}
+	if _, exists := doc.Components.Schemas["entity"]; !exists {
+		t.Error("type argument entity not added to the components")
+	}
+}
+
+func TestGeneratorEmbedded(t *testing.T) {
+	doc, _ := generate(embedding{})
+	s := doc.Components.Schemas["embedding"]
+	if s == nil {
+		t.Fatalf("embedding not added to the components: %v", doc.Components.Schemas)
+	}
+
+	// Fields of embedded structs are promoted, named ones are referenced
+	equalJSON(t, "embedding", s, `{"type":"object","properties":{"createdAt":{"type":"string","format":"date-time"},"id":{"type":"string"},"name":{"type":"string"},"parent":{"$ref":"#/components/schemas/base"}},"required":["id"]}`)
+	if _, exists := doc.Components.Schemas["audit"]; exists {
+		t.Error("promoted struct audit added to the components")
+	}
+}
@@ -0,0 +1,415 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Set of errors returned when a request cannot be validated.
+var (
+	// ErrBodyRequired is returned for a request without the body its
+	// operation requires.
+	ErrBodyRequired = errors.New("body is required")
+
+	// ErrMediaType is returned for a request whose Content-Type is not one
+	// its operation accepts.
+	ErrMediaType = errors.New("unsupported media type")
+)
+
+// templateParam matches a parameter in a path template.
+var templateParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// Violation is a value that does not match the document. Field is the name
+// of the parameter or the path of the value in the body, such as
+// Items[0].Name.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Match is the operation a request was matched to.
+type Match struct {
+	Path      string
+	Operation *Operation
+
+	// Parameters are those of the path item and the operation, with the
+	// operation's replacing those of the same name and location.
+	Parameters []*Parameter
+
+	// Values are the path parameters taken from the request path.
+	Values map[string]string
+}
+
+// route is a path template of the document compiled for matching.
+type route struct {
+	path   string
+	item   *PathItem
+	re     *regexp.Regexp
+	names  []string
+	params int
+}
+
+// Validator validates requests and responses against a document. It is safe
+// for concurrent use.
+type Validator struct {
+	doc    *Document
+	routes []route
+	bases  []string
+}
+
+// NewValidator returns a Validator for doc.
+func NewValidator(doc *Document) (*Validator, error) {
+
+	v := Validator{doc: doc}
+	for path, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+
+		// Parameters match a whole segment or part of one, the rest is
+		// matched literally.
+		var names []string
+		pattern := "^"
+		last := 0
+		for _, loc := range templateParam.FindAllStringSubmatchIndex(path, -1) {
+			pattern += regexp.QuoteMeta(path[last:loc[0]]) + "([^/]+)"
+			names = append(names, path[loc[2]:loc[3]])
+			last = loc[1]
+		}
+		pattern += regexp.QuoteMeta(path[last:]) + "$"
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling path [%v]", path)
+		}
+		v.routes = append(v.routes, route{path: path, item: item, re: re, names: names, params: len(names)})
+	}
+
+	// Concrete paths are matched before templated ones.
+	sort.Slice(v.routes, func(i, j int) bool {
+		if v.routes[i].params != v.routes[j].params {
+			return v.routes[i].params < v.routes[j].params
+		}
+		if len(v.routes[i].path) != len(v.routes[j].path) {
+			return len(v.routes[i].path) > len(v.routes[j].path)
+		}
+		return v.routes[i].path < v.routes[j].path
+	})
+
+	// Paths are relative to the path of the servers.
+	for _, server := range doc.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil || strings.Contains(server.URL, "{") {
+			continue
+		}
+		if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+			v.bases = append(v.bases, base)
+		}
+	}
+
+	return &v, nil
+}
+
+// Find returns the operation matching method and path, or nil when the
+// document does not describe it.
+func (v *Validator) Find(method string, path string) *Match {
+	candidates := []string{path}
+	for _, base := range v.bases {
+		if strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	for _, path := range candidates {
+		for _, rt := range v.routes {
+			values := rt.re.FindStringSubmatch(path)
+			if values == nil {
+				continue
+			}
+			op := rt.item.Operation(method)
+			if op == nil {
+				continue
+			}
+
+			m := Match{Path: rt.path, Operation: op, Values: make(map[string]string)}
+			for i, name := range rt.names {
+				value, err := url.PathUnescape(values[i+1])
+				if err != nil {
+					value = values[i+1]
+				}
+				m.Values[name] = value
+			}
+			m.Parameters = v.parameters(rt.item.Parameters, op.Parameters)
+			return &m
+		}
+	}
+	return nil
+}
+
+// parameters resolves the parameters of a path item and an operation, the
+// operation's replacing the path item's.
+func (v *Validator) parameters(item []*Parameter, op []*Parameter) []*Parameter {
+	var params []*Parameter
+	index := make(map[string]int)
+	for _, p := range append(item[:len(item):len(item)], op...) {
+		p = v.parameter(p)
+		if p == nil {
+			continue
+		}
+		key := p.In + " " + p.Name
+		if p.In == "header" {
+			key = p.In + " " + http.CanonicalHeaderKey(p.Name)
+		}
+		if i, exists := index[key]; exists {
+			params[i] = p
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, p)
+	}
+	return params
+}
+
+// ValidateRequest validates the parameters and body of r against the
+// operation it matched. body is the request body already read from r.
+// Requests the Violations cannot describe, without a required body or with a
+// Content-Type that is not accepted, are reported as an error.
+func (v *Validator) ValidateRequest(m *Match, r *http.Request, body []byte) ([]Violation, error) {
+
+	var out []Violation
+	query := r.URL.Query()
+	for _, p := range m.Parameters {
+
+		// Collect the raw values of the parameter
+		var raw []string
+		switch p.In {
+		case "path":
+			if value, ok := m.Values[p.Name]; ok {
+				raw = []string{value}
+			}
+		case "query":
+			raw = query[p.Name]
+		case "header":
+			// These headers are described by the document in other ways
+			switch http.CanonicalHeaderKey(p.Name) {
+			case "Accept", "Content-Type", "Authorization":
+				continue
+			}
+			raw = r.Header.Values(p.Name)
+		case "cookie":
+			if c, err := r.Cookie(p.Name); err == nil {
+				raw = []string{c.Value}
+			}
+		}
+		if len(raw) == 0 {
+			if p.Required || p.In == "path" {
+				out = append(out, Violation{p.Name, fmt.Sprintf("%s is a required field", p.Name)})
+			}
+			continue
+		}
+
+		s := v.schema(p.Schema)
+		if s == nil {
+			continue
+		}
+		v.check(s, v.parse(s, p.In, raw), p.Name, &out)
+	}
+
+	// Validate the body
+	rb := v.requestBody(m.Operation.RequestBody)
+	if rb == nil {
+		return out, nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if rb.Required {
+			return out, ErrBodyRequired
+		}
+		return out, nil
+	}
+	mt, ok := mediaType(rb.Content, r.Header.Get("Content-Type"))
+	if !ok {
+		return out, ErrMediaType
+	}
+	if mt.Schema == nil || !isJSON(r.Header.Get("Content-Type")) {
+		return out, nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return out, errors.Wrap(err, "decoding body")
+	}
+	v.check(v.schema(mt.Schema), value, "", &out)
+	return out, nil
+}
+
+// ValidateResponse validates a response written for the operation m matched.
+func (v *Validator) ValidateResponse(m *Match, status int, header http.Header, body []byte) []Violation {
+
+	responses := m.Operation.Responses
+	resp, ok := responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = responses[fmt.Sprintf("%dXX", status/100)]
+	}
+	if !ok {
+		resp, ok = responses["default"]
+	}
+	if !ok {
+		return []Violation{{"status", fmt.Sprintf("status %d is not documented", status)}}
+	}
+
+	resp = v.response(resp)
+	if resp == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if len(resp.Content) == 0 {
+		return []Violation{{"body", fmt.Sprintf("status %d is documented without a body", status)}}
+	}
+	contentType := header.Get("Content-Type")
+	mt, ok := mediaType(resp.Content, contentType)
+	if !ok {
+		return []Violation{{"Content-Type", fmt.Sprintf("Content-Type %s is not documented for status %d", contentType, status)}}
+	}
+	if mt.Schema == nil || !isJSON(contentType) {
+		return nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return []Violation{{"body", "body must be valid JSON"}}
+	}
+	var out []Violation
+	v.check(v.schema(mt.Schema), value, "", &out)
+	return out
+}
+
+// parse converts the raw values of a parameter into the value its schema
+// describes. Values that do not parse are left as strings for check to
+// report.
+func (v *Validator) parse(s *Schema, in string, raw []string) interface{} {
+	if !isType(s, "array") {
+		return scalar(s, raw[0])
+	}
+
+	// Headers hold a comma separated list, queries repeat the parameter
+	if in != "query" {
+		var values []string
+		for _, r := range raw {
+			values = append(values, strings.Split(r, ",")...)
+		}
+		raw = values
+	}
+	items := make([]interface{}, len(raw))
+	for i, r := range raw {
+		items[i] = scalar(v.schema(s.Items), strings.TrimSpace(r))
+	}
+	return items
+}
+
+// scalar converts raw into a number or boolean when s requires one.
+func scalar(s *Schema, raw string) interface{} {
+	switch {
+	case isType(s, "integer"), isType(s, "number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case isType(s, "boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// isType reports whether s explicitly allows typ.
+func isType(s *Schema, typ string) bool {
+	return s != nil && len(s.Type) > 0 && s.Type.Has(typ)
+}
+
+// schema resolves a reference to a component schema. Unresolved references
+// return an empty schema, which accepts any value.
+func (v *Validator) schema(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		next, ok := v.doc.Components.Schemas[s.RefName()]
+		if !ok {
+			return &Schema{}
+		}
+		s = next
+	}
+	return s
+}
+
+// parameter resolves a reference to a component parameter.
+func (v *Validator) parameter(p *Parameter) *Parameter {
+	if p == nil || p.Ref == "" {
+		return p
+	}
+	return v.doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+}
+
+// requestBody resolves a reference to a component request body.
+func (v *Validator) requestBody(rb *RequestBody) *RequestBody {
+	if rb == nil || rb.Ref == "" {
+		return rb
+	}
+	return v.doc.Components.RequestBodies[strings.TrimPrefix(rb.Ref, "#/components/requestBodies/")]
+}
+
+// response resolves a reference to a component response.
+func (v *Validator) response(resp *Response) *Response {
+	if resp == nil || resp.Ref == "" {
+		return resp
+	}
+	return v.doc.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")]
+}
+
+// mediaType returns the media type of content matching contentType, trying
+// the exact type, then type/* and then */*. A missing Content-Type is taken
+// as JSON.
+func mediaType(content map[string]MediaType, contentType string) (MediaType, bool) {
+	mt := "application/json"
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return MediaType{}, false
+		}
+		mt = parsed
+	}
+
+	candidates := []string{mt, strings.SplitN(mt, "/", 2)[0] + "/*", "*/*"}
+	for _, candidate := range candidates {
+		if m, ok := content[candidate]; ok {
+			return m, true
+		}
+	}
+	return MediaType{}, false
+}
+
+// isJSON reports whether contentType is JSON, a missing Content-Type is.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// decode decodes a JSON body keeping numbers exact.
+func decode(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
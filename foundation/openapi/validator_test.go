@@ -0,0 +1,299 @@
+package openapi_test
+
+import (
+	"bytes"
+	"dev/yourservice.git/foundation/openapi"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// newValidator returns a Validator for the document in testdata/api.yaml.
+func newValidator(t *testing.T) *openapi.Validator {
+	t.Helper()
+	doc, err := openapi.Load(filepath.Join("testdata", "api.yaml"))
+	if err != nil {
+		t.Fatalf("loading document: %v", err)
+	}
+	v, err := openapi.NewValidator(doc)
+	if err != nil {
+		t.Fatalf("creating validator: %v", err)
+	}
+	return v
+}
+
+func TestLoad(t *testing.T) {
+	doc, err := openapi.Load(filepath.Join("testdata", "api.yaml"))
+	if err != nil {
+		t.Fatalf("loading yaml: %v", err)
+	}
+	if doc.Paths["/items"].Get.Responses["200"] == nil {
+		t.Fatal("the unquoted status 200 was not read as a response")
+	}
+	if ref := doc.Paths["/items"].Post.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/Item" {
+		t.Fatalf("got schema ref [%v]", ref)
+	}
+
+	// The same document as JSON loads the same
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "api.json")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := openapi.Load(name)
+	if err != nil {
+		t.Fatalf("loading json: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, doc) {
+		t.Fatal("json and yaml documents differ")
+	}
+
+	// Only OpenAPI 3 documents are supported
+	name = filepath.Join(t.TempDir(), "swagger.yml")
+	if err := os.WriteFile(name, []byte("swagger: \"2.0\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openapi.Load(name); err == nil {
+		t.Fatal("loaded a swagger 2.0 document")
+	}
+}
+
+func TestFind(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+		values map[string]string
+	}{
+		{"concrete", http.MethodGet, "/items", "/items", map[string]string{}},
+		{"concrete before templated", http.MethodGet, "/items/latest", "/items/latest", map[string]string{}},
+		{"templated", http.MethodGet, "/items/42", "/items/{id}", map[string]string{"id": "42"}},
+		{"escaped parameter", http.MethodGet, "/items/a%2Fb", "/items/{id}", map[string]string{"id": "a/b"}},
+		{"parameters in a segment", http.MethodGet, "/files/report.pdf", "/files/{name}.{ext}", map[string]string{"name": "report", "ext": "pdf"}},
+		{"server base path", http.MethodGet, "/v1/items/latest", "/items/latest", map[string]string{}},
+		{"relative server base path", http.MethodGet, "/v2/items/7", "/items/{id}", map[string]string{"id": "7"}},
+		{"unknown base path", http.MethodGet, "/v3/items", "", nil},
+		{"unknown method", http.MethodDelete, "/items/1", "", nil},
+		{"unknown path", http.MethodGet, "/other", "", nil},
+		{"extra segment", http.MethodGet, "/items/1/more", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := v.Find(tt.method, tt.path)
+			if tt.want == "" {
+				if m != nil {
+					t.Fatalf("matched [%v], want no match", m.Path)
+				}
+				return
+			}
+			if m == nil {
+				t.Fatalf("no match, want [%v]", tt.want)
+			}
+			if m.Path != tt.want || !reflect.DeepEqual(m.Values, tt.values) {
+				t.Fatalf("matched [%v] %v, want [%v] %v", m.Path, m.Values, tt.want, tt.values)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		header  http.Header
+		body    string
+		want    []openapi.Violation
+		wantErr error
+	}{
+		{
+			name:   "valid query",
+			method: http.MethodGet,
+			target: "/items?limit=5&tag=a&tag=b",
+		},
+		{
+			name:   "query array item",
+			method: http.MethodGet,
+			target: "/items?limit=5&tag=a&tag=c",
+			want:   []openapi.Violation{{Field: "tag[1]", Message: "tag[1] must be one of [a b]"}},
+		},
+		{
+			name:   "missing required query",
+			method: http.MethodGet,
+			target: "/items",
+			want:   []openapi.Violation{{Field: "limit", Message: "limit is a required field"}},
+		},
+		{
+			name:   "query out of range",
+			method: http.MethodGet,
+			target: "/items?limit=500",
+			want:   []openapi.Violation{{Field: "limit", Message: "limit must be 100 or less"}},
+		},
+		{
+			name:   "query of the wrong type",
+			method: http.MethodGet,
+			target: "/items?limit=x",
+			want:   []openapi.Violation{{Field: "limit", Message: "limit must be of type integer"}},
+		},
+		{
+			name:   "header array",
+			method: http.MethodGet,
+			target: "/items?limit=1",
+			header: http.Header{"X-Ids": {"1, 2", "3"}},
+		},
+		{
+			name:   "header array item",
+			method: http.MethodGet,
+			target: "/items?limit=1",
+			header: http.Header{"X-Ids": {"1,x"}},
+			want:   []openapi.Violation{{Field: "X-Ids[1]", Message: "X-Ids[1] must be of type integer"}},
+		},
+		{
+			name:   "path parameter",
+			method: http.MethodGet,
+			target: "/items/x",
+			want:   []openapi.Violation{{Field: "id", Message: "id must be of type integer"}},
+		},
+		{
+			name:   "valid body",
+			method: http.MethodPost,
+			target: "/items",
+			header: http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+			body:   `{"name":"a"}`,
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost,
+			target: "/items",
+			body:   `{"name":1}`,
+			want:   []openapi.Violation{{Field: "name", Message: "name must be of type string"}},
+		},
+		{
+			name:    "missing required body",
+			method:  http.MethodPost,
+			target:  "/items",
+			body:    " ",
+			wantErr: openapi.ErrBodyRequired,
+		},
+		{
+			name:    "unsupported media type",
+			method:  http.MethodPost,
+			target:  "/items",
+			header:  http.Header{"Content-Type": {"text/plain"}},
+			body:    `{"name":"a"}`,
+			wantErr: openapi.ErrMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			for k, vs := range tt.header {
+				r.Header[k] = vs
+			}
+			m := v.Find(r.Method, r.URL.Path)
+			if m == nil {
+				t.Fatalf("no operation for [%v %v]", r.Method, r.URL.Path)
+			}
+
+			got, err := v.ValidateRequest(m, r, []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got violations %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// validateBody returns the violations of body against the JSON schema.
+func validateBody(t *testing.T, schema string, body string) []openapi.Violation {
+	t.Helper()
+	var s openapi.Schema
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		t.Fatalf("decoding schema: %v", err)
+	}
+	doc := openapi.Document{
+		OpenAPI: openapi.Version,
+		Paths: map[string]*openapi.PathItem{
+			"/": {Post: &openapi.Operation{
+				RequestBody: &openapi.RequestBody{
+					Content: map[string]openapi.MediaType{"application/json": {Schema: &s}},
+				},
+			}},
+		},
+	}
+	v, err := openapi.NewValidator(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	out, err := v.ValidateRequest(v.Find(r.Method, r.URL.Path), r, []byte(body))
+	if err != nil {
+		t.Fatalf("validating: %v", err)
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		body   string
+		want   []openapi.Violation
+	}{
+		{"one of", `{"oneOf":[{"type":"string"},{"type":"integer"}]}`, `"x"`, nil},
+		{"one of none", `{"oneOf":[{"type":"string"},{"type":"integer"}]}`, `1.5`,
+			[]openapi.Violation{{Field: "body", Message: "body must match exactly one of the allowed schemas"}}},
+		{"one of several", `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`,
+			[]openapi.Violation{{Field: "body", Message: "body must match exactly one of the allowed schemas"}}},
+		{"any of", `{"anyOf":[{"type":"string","minLength":3},{"type":"integer"}]}`, `"abc"`, nil},
+		{"any of none", `{"anyOf":[{"type":"string","minLength":3},{"type":"integer"}]}`, `"ab"`,
+			[]openapi.Violation{{Field: "body", Message: "body must match at least one of the allowed schemas"}}},
+		{"all of", `{"allOf":[{"required":["a"]},{"required":["b"]}]}`, `{"a":1}`,
+			[]openapi.Violation{{Field: "b", Message: "b is a required field"}}},
+		{"additional properties allowed", `{"type":"object","properties":{"a":{}}}`, `{"a":1,"b":2}`, nil},
+		{"additional properties false", `{"type":"object","properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`,
+			[]openapi.Violation{{Field: "b", Message: "b is not allowed"}}},
+		{"additional properties schema", `{"type":"object","additionalProperties":{"type":"integer"}}`, `{"a":1,"b":"x"}`,
+			[]openapi.Violation{{Field: "b", Message: "b must be of type integer"}}},
+		{"nested path", `{"type":"object","properties":{"items":{"type":"array","items":{"type":"object","properties":{"n":{"type":"integer"}}}}}}`, `{"items":[{"n":1},{"n":"x"}]}`,
+			[]openapi.Violation{{Field: "items[1].n", Message: "n must be of type integer"}}},
+		{"date-time", `{"type":"string","format":"date-time"}`, `"2024-01-02T03:04:05Z"`, nil},
+		{"invalid date-time", `{"type":"string","format":"date-time"}`, `"yesterday"`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid date-time"}}},
+		{"invalid date", `{"type":"string","format":"date"}`, `"2024-13-01"`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid date"}}},
+		{"invalid email", `{"type":"string","format":"email"}`, `"Ann <ann@example.com>"`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid email"}}},
+		{"uuid", `{"type":"string","format":"uuid"}`, `"123e4567-e89b-12d3-a456-426614174000"`, nil},
+		{"invalid uri", `{"type":"string","format":"uri"}`, `"/relative"`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid uri"}}},
+		{"invalid ipv4", `{"type":"string","format":"ipv4"}`, `"::1"`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid ipv4"}}},
+		{"invalid int32", `{"type":"integer","format":"int32"}`, `3000000000`,
+			[]openapi.Violation{{Field: "body", Message: "body must be a valid int32"}}},
+		{"unknown format", `{"type":"string","format":"color"}`, `"red"`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateBody(t, tt.schema, tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got violations %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
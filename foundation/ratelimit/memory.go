@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sweepEvery is how often the MemoryStore drops idle keys.
+const sweepEvery = time.Minute
+
+// state is the stored state of one key. A TokenBucket uses tokens and at, a
+// SlidingWindow uses the counts of the window starting at at and the one
+// before it.
+type state struct {
+	tokens   float64
+	at       time.Time
+	current  int
+	previous int
+	expires  time.Time
+}
+
+// MemoryStore is a Store local to this process. The zero value is ready to
+// use.
+type MemoryStore struct {
+
+	// Now returns the current time, time.Now when nil. Tests set it to
+	// control the passing of time.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	keys  map[string]*state
+	swept time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Take implements Store.
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if m.Now != nil {
+		now = m.Now()
+	}
+	if m.keys == nil {
+		m.keys = make(map[string]*state)
+	}
+	m.sweep(now)
+
+	s, ok := m.keys[key]
+	if !ok {
+		s = &state{}
+		m.keys[key] = s
+	}
+
+	switch limit.Algorithm {
+	case TokenBucket:
+		return s.tokenBucket(now, limit), nil
+	case SlidingWindow:
+		return s.slidingWindow(now, limit), nil
+	}
+	return Result{}, errors.Errorf("unknown rate limit algorithm [%v]", limit.Algorithm)
+}
+
+// sweep drops keys whose state has fully reset so idle callers do not grow
+// the map forever.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepEvery {
+		return
+	}
+	m.swept = now
+	for key, s := range m.keys {
+		if now.After(s.expires) {
+			delete(m.keys, key)
+		}
+	}
+}
+
+// tokenBucket refills the bucket for the time elapsed and takes one token.
+func (s *state) tokenBucket(now time.Time, limit Limit) Result {
+
+	size := float64(limit.Burst)
+	if limit.Burst <= 0 {
+		size = float64(limit.Requests)
+	}
+	rate := float64(limit.Requests) / limit.Period.Seconds()
+
+	if s.at.IsZero() {
+		s.tokens = size
+	} else {
+		s.tokens = math.Min(size, s.tokens+now.Sub(s.at).Seconds()*rate)
+	}
+	s.at = now
+
+	res := Result{Limit: int(size)}
+	if s.tokens >= 1 {
+		s.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - s.tokens) / rate)
+	}
+	res.Remaining = int(s.tokens)
+	res.Reset = seconds((size - s.tokens) / rate)
+	s.expires = now.Add(res.Reset)
+	return res
+}
+
+// slidingWindow estimates the requests in the last Period from the current
+// and previous fixed windows and counts one more if it fits.
+func (s *state) slidingWindow(now time.Time, limit Limit) Result {
+
+	start := now.Truncate(limit.Period)
+	switch {
+	case s.at.Equal(start):
+	case s.at.Add(limit.Period).Equal(start):
+		s.previous, s.current = s.current, 0
+	default:
+		s.previous, s.current = 0, 0
+	}
+	s.at = start
+
+	elapsed := now.Sub(start)
+	overlap := 1 - float64(elapsed)/float64(limit.Period)
+	estimate := float64(s.previous)*overlap + float64(s.current)
+	max := float64(limit.Requests)
+
+	res := Result{Limit: limit.Requests}
+	if estimate+1 <= max {
+		s.current++
+		estimate++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = s.retryAfter(elapsed, limit)
+	}
+	res.Remaining = int(math.Max(0, math.Floor(max-estimate)))
+	res.Reset = limit.Period - elapsed
+	s.expires = start.Add(2 * limit.Period)
+	return res
+}
+
+// retryAfter returns how long until the estimate leaves room for one more
+// request.
+func (s *state) retryAfter(elapsed time.Duration, limit Limit) time.Duration {
+	room := float64(limit.Requests - 1)
+	period := float64(limit.Period)
+
+	// The current window alone is full, wait for it to become the previous
+	// window and fade enough.
+	if float64(s.current) > room {
+		return limit.Period - elapsed + time.Duration(period*(1-room/float64(s.current)))
+	}
+
+	// Otherwise wait for the previous window to fade enough.
+	wait := time.Duration(period*(1-(room-float64(s.current))/float64(s.previous))) - elapsed
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// seconds converts fractional seconds to a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
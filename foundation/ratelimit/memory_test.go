@@ -0,0 +1,144 @@
+package ratelimit_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/ratelimit"
+	"testing"
+	"time"
+)
+
+// clock is a time source advanced by the tests.
+type clock struct {
+	now time.Time
+}
+
+// Now returns the current time of the clock.
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// newStore returns a MemoryStore with a clock at the start of a minute.
+func newStore() (*ratelimit.MemoryStore, *clock) {
+	c := clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return &ratelimit.MemoryStore{Now: c.Now}, &c
+}
+
+// step is a request taken after advancing the clock by after.
+type step struct {
+	after      time.Duration
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// run takes the steps from one key of store.
+func run(t *testing.T, store *ratelimit.MemoryStore, c *clock, limit ratelimit.Limit, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		c.now = c.now.Add(s.after)
+		res, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatalf("step %v: %v", i, err)
+		}
+		want := ratelimit.Result{Allowed: s.allowed, Limit: res.Limit, Remaining: s.remaining, Reset: s.reset, RetryAfter: s.retryAfter}
+		if res != want {
+			t.Fatalf("step %v: got %+v, want %+v", i, res, want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	store, c := newStore()
+	limit := ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Requests: 2, Period: time.Second, Burst: 4}
+
+	run(t, store, c, limit, []step{
+		// A full bucket allows a burst
+		{0, true, 3, 500 * time.Millisecond, 0},
+		{0, true, 2, time.Second, 0},
+		{0, true, 1, 1500 * time.Millisecond, 0},
+		{0, true, 0, 2 * time.Second, 0},
+		{0, false, 0, 2 * time.Second, 500 * time.Millisecond},
+
+		// Tokens refill at Requests per Period
+		{500 * time.Millisecond, true, 0, 2 * time.Second, 0},
+		{250 * time.Millisecond, false, 0, 1750 * time.Millisecond, 250 * time.Millisecond},
+
+		// Up to the burst
+		{time.Minute, true, 3, 500 * time.Millisecond, 0},
+	})
+
+	res, err := store.Take(context.Background(), "key", limit)
+	if err != nil || res.Limit != 4 {
+		t.Fatalf("got limit %v %v, want the burst", res.Limit, err)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	store, c := newStore()
+	limit := ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Requests: 4, Period: time.Minute}
+
+	run(t, store, c, limit, []step{
+		{0, true, 3, time.Minute, 0},
+		{0, true, 2, time.Minute, 0},
+		{0, true, 1, time.Minute, 0},
+		{0, true, 0, time.Minute, 0},
+
+		// The full window has to end and fade to a quarter
+		{0, false, 0, time.Minute, 75 * time.Second},
+
+		// Half way through the next window half of the previous counts
+		{90 * time.Second, true, 1, 30 * time.Second, 0},
+		{0, true, 0, 30 * time.Second, 0},
+		{0, false, 0, 30 * time.Second, 15 * time.Second},
+
+		// Windows older than the previous one are forgotten
+		{3 * time.Minute, true, 3, 30 * time.Second, 0},
+	})
+}
+
+func TestTake(t *testing.T) {
+	store, _ := newStore()
+	ctx := context.Background()
+	limit := ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Requests: 1, Period: time.Minute}
+
+	// Keys are counted separately
+	for _, key := range []string{"a", "b"} {
+		if res, err := store.Take(ctx, key, limit); err != nil || !res.Allowed {
+			t.Fatalf("key [%v]: got %+v %v, want allowed", key, res, err)
+		}
+	}
+
+	// A disabled limit allows everything
+	if res, err := store.Take(ctx, "a", ratelimit.Limit{}); err != nil || !res.Allowed {
+		t.Fatalf("got %+v %v, want allowed", res, err)
+	}
+
+	limit.Algorithm = "leaky_bucket"
+	if _, err := store.Take(ctx, "a", limit); err == nil {
+		t.Fatal("took from an unknown algorithm")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    ratelimit.Limit
+		wantErr bool
+	}{
+		{"100/1m", ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Requests: 100, Period: time.Minute}, false},
+		{" 5 / 10s ", ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Requests: 5, Period: 10 * time.Second}, false},
+		{"100", ratelimit.Limit{}, true},
+		{"x/1m", ratelimit.Limit{}, true},
+		{"0/1m", ratelimit.Limit{}, true},
+		{"100/0s", ratelimit.Limit{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ratelimit.ParseLimit(ratelimit.TokenBucket, tt.s)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("got %+v %v, want %+v error [%v]", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
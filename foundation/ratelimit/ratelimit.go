@@ -0,0 +1,89 @@
+// Package ratelimit provides token bucket and sliding window rate limits
+// over pluggable storage.
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Algorithm selects how a Limit counts requests.
+type Algorithm string
+
+// Set of supported algorithms.
+const (
+	// TokenBucket refills Requests tokens per Period up to Burst, allowing
+	// short bursts above the average rate.
+	TokenBucket Algorithm = "token_bucket"
+
+	// SlidingWindow allows Requests per Period, weighting the previous
+	// window by how much of it still overlaps the sliding window.
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit describes how many requests a key may make.
+type Limit struct {
+	Algorithm Algorithm
+	Requests  int
+	Period    time.Duration
+
+	// Burst is the bucket size of a TokenBucket, it defaults to Requests.
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Result is the outcome of taking one request from a limit.
+type Result struct {
+	Allowed bool
+
+	// Limit and Remaining are the quota and what is left of it.
+	Limit     int
+	Remaining int
+
+	// Reset is the time until the quota is refilled, for a SlidingWindow
+	// the end of the current window.
+	Reset time.Duration
+
+	// RetryAfter is the time until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Store holds the state of every key. Take must count the request and decide
+// atomically so limits hold across concurrent requests and, for shared
+// implementations, across instances.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ParseLimit parses a limit written as <requests>/<period>, for example
+// 100/1m.
+func ParseLimit(algorithm Algorithm, s string) (Limit, error) {
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, errors.Errorf("limit [%v] is not <requests>/<period>", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Limit{}, errors.Errorf("limit [%v] requests must be a positive number", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, errors.Errorf("limit [%v] period must be a positive duration", s)
+	}
+
+	switch algorithm {
+	case TokenBucket, SlidingWindow:
+	default:
+		return Limit{}, errors.Errorf("unknown rate limit algorithm [%v]", algorithm)
+	}
+	return Limit{Algorithm: algorithm, Requests: n, Period: d}, nil
+}
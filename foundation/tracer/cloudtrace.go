@@ -0,0 +1,80 @@
+package tracer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cloudTraceHeader is the header set by Google Cloud load balancers and App
+// Engine. Its format is TRACE_ID/SPAN_ID;o=OPTIONS where SPAN_ID is decimal.
+const cloudTraceHeader = "X-Cloud-Trace-Context"
+
+// CloudTraceContext propagates span context in the X-Cloud-Trace-Context
+// header.
+type CloudTraceContext struct{}
+
+// Inject sets the header from the span context in ctx.
+func (CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	spanID := sc.SpanID()
+	var sampled int
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	carrier.Set(cloudTraceHeader, fmt.Sprintf("%v/%d;o=%d",
+		sc.TraceID(), binary.BigEndian.Uint64(spanID[:]), sampled,
+	))
+}
+
+// Extract returns ctx with the remote span context read from the header. An
+// invalid header leaves ctx unchanged.
+func (CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	h := carrier.Get(cloudTraceHeader)
+	if h == "" {
+		return ctx
+	}
+
+	// Split TRACE_ID/SPAN_ID;o=OPTIONS
+	traceHex, rest, ok := strings.Cut(h, "/")
+	if !ok {
+		return ctx
+	}
+	spanDec, options, _ := strings.Cut(rest, ";")
+
+	traceID, err := trace.TraceIDFromHex(traceHex)
+	if err != nil {
+		return ctx
+	}
+	n, err := strconv.ParseUint(spanDec, 10, 64)
+	if err != nil || n == 0 {
+		return ctx
+	}
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], n)
+
+	var flags trace.TraceFlags
+	if options == "o=1" {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields returns the header set by Inject.
+func (CloudTraceContext) Fields() []string {
+	return []string{cloudTraceHeader}
+}
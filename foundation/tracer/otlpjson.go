@@ -0,0 +1,282 @@
+package tracer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// JSONExporter writes spans in the OTLP JSON encoding, one
+// ExportTraceServiceRequest per line, as the OpenTelemetry file exporter
+// does. The output can be replayed into any OTLP collector.
+type JSONExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONExporter returns an exporter writing to w. When closer is not nil
+// it is closed by Shutdown.
+func NewJSONExporter(w io.Writer, closer io.Closer) *JSONExporter {
+	return &JSONExporter{w: w, closer: closer}
+}
+
+// ExportSpans writes spans as a single line, grouped by resource and
+// instrumentation scope.
+func (e *JSONExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	// Group the spans keeping the order they were ended in
+	var req otlpRequest
+	resources := make(map[*resource.Resource]int)
+	scopes := make(map[*resource.Resource]map[instrumentation.Scope]int)
+	for _, s := range spans {
+		res := s.Resource()
+		ri, exists := resources[res]
+		if !exists {
+			ri = len(req.ResourceSpans)
+			resources[res] = ri
+			scopes[res] = make(map[instrumentation.Scope]int)
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{Attributes: otlpAttributes(res.Attributes())},
+			})
+		}
+		rs := &req.ResourceSpans[ri]
+
+		scope := s.InstrumentationScope()
+		si, exists := scopes[res][scope]
+		if !exists {
+			si = len(rs.ScopeSpans)
+			scopes[res][scope] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{
+				Scope:     otlpScope{Name: scope.Name, Version: scope.Version},
+				SchemaURL: scope.SchemaURL,
+			})
+		}
+		rs.ScopeSpans[si].Spans = append(rs.ScopeSpans[si].Spans, otlpSpanOf(s))
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "encoding spans")
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.w == nil {
+		return errors.New("exporter is shut down")
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return errors.Wrap(err, "writing spans")
+	}
+	return nil
+}
+
+// Shutdown stops the exporter and closes its writer.
+func (e *JSONExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w = nil
+	if e.closer == nil {
+		return nil
+	}
+	closer := e.closer
+	e.closer = nil
+	if err := closer.Close(); err != nil {
+		return errors.Wrap(err, "closing trace file")
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of ExportTraceServiceRequest. Trace and span IDs are
+// hex strings, 64 bit integers are decimal strings and enums are numbers.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpScopeSpans struct {
+		Scope     otlpScope  `json:"scope"`
+		Spans     []otlpSpan `json:"spans"`
+		SchemaURL string     `json:"schemaUrl,omitempty"`
+	}
+
+	otlpScope struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceID                string         `json:"traceId"`
+		SpanID                 string         `json:"spanId"`
+		TraceState             string         `json:"traceState,omitempty"`
+		ParentSpanID           string         `json:"parentSpanId,omitempty"`
+		Name                   string         `json:"name"`
+		Kind                   int            `json:"kind"`
+		StartTimeUnixNano      string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano        string         `json:"endTimeUnixNano"`
+		Attributes             []otlpKeyValue `json:"attributes,omitempty"`
+		DroppedAttributesCount int            `json:"droppedAttributesCount,omitempty"`
+		Events                 []otlpEvent    `json:"events,omitempty"`
+		DroppedEventsCount     int            `json:"droppedEventsCount,omitempty"`
+		Links                  []otlpLink     `json:"links,omitempty"`
+		DroppedLinksCount      int            `json:"droppedLinksCount,omitempty"`
+		Status                 otlpStatus     `json:"status"`
+	}
+
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpLink struct {
+		TraceID    string         `json:"traceId"`
+		SpanID     string         `json:"spanId"`
+		TraceState string         `json:"traceState,omitempty"`
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+
+	otlpStatus struct {
+		Message string `json:"message,omitempty"`
+		Code    int    `json:"code,omitempty"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string         `json:"stringValue,omitempty"`
+		BoolValue   *bool           `json:"boolValue,omitempty"`
+		IntValue    *string         `json:"intValue,omitempty"`
+		DoubleValue *float64        `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	}
+
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+)
+
+// otlpSpanOf converts a span to its OTLP form.
+func otlpSpanOf(s sdktrace.ReadOnlySpan) otlpSpan {
+	sc := s.SpanContext()
+	span := otlpSpan{
+		TraceID:                sc.TraceID().String(),
+		SpanID:                 sc.SpanID().String(),
+		TraceState:             sc.TraceState().String(),
+		Name:                   s.Name(),
+		Kind:                   int(s.SpanKind()),
+		StartTimeUnixNano:      strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:        strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:             otlpAttributes(s.Attributes()),
+		DroppedAttributesCount: s.DroppedAttributes(),
+		DroppedEventsCount:     s.DroppedEvents(),
+		DroppedLinksCount:      s.DroppedLinks(),
+		Status:                 otlpStatus{Message: s.Status().Description},
+	}
+	if parent := s.Parent(); parent.HasSpanID() {
+		span.ParentSpanID = parent.SpanID().String()
+	}
+
+	// OTLP numbers the status codes differently from the API
+	switch s.Status().Code {
+	case codes.Ok:
+		span.Status.Code = 1
+	case codes.Error:
+		span.Status.Code = 2
+	}
+
+	for _, ev := range s.Events() {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(ev.Time.UnixNano(), 10),
+			Name:         ev.Name,
+			Attributes:   otlpAttributes(ev.Attributes),
+		})
+	}
+	for _, l := range s.Links() {
+		span.Links = append(span.Links, otlpLink{
+			TraceID:    l.SpanContext.TraceID().String(),
+			SpanID:     l.SpanContext.SpanID().String(),
+			TraceState: l.SpanContext.TraceState().String(),
+			Attributes: otlpAttributes(l.Attributes),
+		})
+	}
+	return span
+}
+
+// otlpAttributes converts attributes to OTLP key values.
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, len(attrs))
+	for i, kv := range attrs {
+		kvs[i] = otlpKeyValue{Key: string(kv.Key), Value: otlpValue(kv.Value)}
+	}
+	return kvs
+}
+
+// otlpValue converts an attribute value to an OTLP AnyValue.
+func otlpValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		n := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &n}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		var values []otlpAnyValue
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, otlpValue(attribute.BoolValue(b)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		var values []otlpAnyValue
+		for _, n := range v.AsInt64Slice() {
+			values = append(values, otlpValue(attribute.Int64Value(n)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		var values []otlpAnyValue
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, otlpValue(attribute.Float64Value(f)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		var values []otlpAnyValue
+		for _, s := range v.AsStringSlice() {
+			values = append(values, otlpValue(attribute.StringValue(s)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	}
+	s := v.Emit()
+	return otlpAnyValue{StringValue: &s}
+}
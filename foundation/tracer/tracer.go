@@ -0,0 +1,78 @@
+// Package tracer configures OpenTelemetry tracing for the service. It honours
+// and propagates both W3C traceparent and Google's X-Cloud-Trace-Context
+// headers.
+package tracer
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Set of exporters supported by NewExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// NewExporter returns one of the built in exporters for local testing: spans
+// are written in the OTLP JSON encoding to stdout or to the named file, which
+// is closed when the exporter is shut down. ExporterNone returns a nil
+// exporter. Production deployments can pass any other sdktrace.SpanExporter,
+// such as an OTLP exporter, to Init instead.
+func NewExporter(kind string, path string) (sdktrace.SpanExporter, error) {
+
+	switch kind {
+	case ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return NewJSONExporter(os.Stdout, nil), nil
+	case ExporterFile:
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening trace file")
+		}
+		return NewJSONExporter(f, f), nil
+	}
+	return nil, errors.Errorf("unknown trace exporter [%v]", kind)
+}
+
+// Init installs a global TracerProvider and propagator. Spans are sampled at
+// sampleRatio unless the caller's sampling decision says otherwise. Trace IDs
+// are generated even when exporter is nil so requests can still be
+// correlated in logs. The returned provider must be shut down to flush
+// pending spans.
+func Init(serviceName string, exporter sdktrace.SpanExporter, sampleRatio float64) *sdktrace.TracerProvider {
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+		)),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
+	return tp
+}
+
+// Propagator returns the propagator used for incoming and outgoing requests.
+// traceparent takes precedence over X-Cloud-Trace-Context when both are
+// present.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(
+		CloudTraceContext{},
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+}
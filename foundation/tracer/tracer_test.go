@@ -0,0 +1,122 @@
+package tracer_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/tracer"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewExporter(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		kind    string
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{tracer.ExporterNone, "", false, false},
+		{tracer.ExporterStdout, "", true, false},
+		{tracer.ExporterFile, filepath.Join(dir, "spans.json"), true, false},
+		{tracer.ExporterFile, filepath.Join(dir, "missing", "spans.json"), false, true},
+		{"zipkin", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			exporter, err := tracer.NewExporter(tt.kind, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error [%v]", err, tt.wantErr)
+			}
+			if (exporter != nil) != tt.want {
+				t.Fatalf("got exporter %v, want one [%v]", exporter, tt.want)
+			}
+			if exporter != nil {
+				if err := exporter.Shutdown(context.Background()); err != nil {
+					t.Fatalf("shutting down: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPropagator(t *testing.T) {
+	const (
+		traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		cloudTrace  = "105445aa7843bc8bf206b12000100000/1;o=1"
+	)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		trace  string
+		span   string
+	}{
+		{"traceparent", http.Header{"Traceparent": {traceparent}}, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+		{"cloud trace", http.Header{"X-Cloud-Trace-Context": {cloudTrace}}, "105445aa7843bc8bf206b12000100000", "0000000000000001"},
+		{"traceparent first", http.Header{"Traceparent": {traceparent}, "X-Cloud-Trace-Context": {cloudTrace}}, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+		{"invalid cloud trace", http.Header{"X-Cloud-Trace-Context": {"zz/1;o=1"}}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tracer.Propagator().Extract(context.Background(), propagation.HeaderCarrier(tt.header))
+			sc := trace.SpanContextFromContext(ctx)
+			if tt.trace == "" {
+				if sc.IsValid() {
+					t.Fatalf("extracted %v from an invalid header", sc.TraceID())
+				}
+				return
+			}
+			if sc.TraceID().String() != tt.trace || sc.SpanID().String() != tt.span || !sc.IsSampled() {
+				t.Fatalf("extracted %v %v sampled [%v], want %v %v", sc.TraceID(), sc.SpanID(), sc.IsSampled(), tt.trace, tt.span)
+			}
+
+			// Outgoing requests carry both headers
+			out := http.Header{}
+			tracer.Propagator().Inject(ctx, propagation.HeaderCarrier(out))
+			if out.Get("Traceparent") == "" || out.Get("X-Cloud-Trace-Context") == "" {
+				t.Fatalf("injected headers %v", out)
+			}
+		})
+	}
+}
+
+func TestTraceThroughApp(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracer.Init("test", exporter, 0)
+	defer tp.Shutdown(context.Background())
+
+	var traceID string
+	app := web.NewApp(make(chan os.Signal, 1))
+	app.Handle(http.MethodGet, "/entities/:id", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		traceID = ctx.Value(web.KeyValues).(*web.Values).TraceID
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	})
+
+	// The caller's sampling decision overrides the zero sample ratio
+	r := httptest.NewRequest(http.MethodGet, "/entities/a", nil)
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	app.ServeHTTP(httptest.NewRecorder(), r)
+	if err := tp.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("request has trace id [%v], want the caller's", traceID)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %v spans, want 1", len(spans))
+	}
+	if s := spans[0]; s.Name != "GET /entities/:id" || s.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("exported span [%v] with parent [%v]", s.Name, s.Parent.SpanID())
+	}
+}
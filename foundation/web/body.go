@@ -0,0 +1,47 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// bodyLimit limits the request body to n bytes. The limit is applied on the
+// first read so route middleware can still change it.
+type bodyLimit struct {
+	io.ReadCloser
+	w      http.ResponseWriter
+	n      int64
+	reader io.ReadCloser
+}
+
+// Read reads from the limited body, once more than n bytes were read it
+// returns an *http.MaxBytesError.
+func (b *bodyLimit) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		b.reader = http.MaxBytesReader(b.w, b.ReadCloser, b.n)
+	}
+	return b.reader.Read(p)
+}
+
+// MaxBodyBytes overrides the App's request body limit for a route.
+func MaxBodyBytes(n int64) Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(handler Handler) Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if body, ok := ctx.Value(keyBodyLimit).(*bodyLimit); ok && body.reader == nil {
+				body.n = n
+			}
+
+			// Call the next handler.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
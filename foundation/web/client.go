@@ -0,0 +1,423 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Set of errors returned by Client.
+var (
+	// ErrCircuitOpen is returned without sending the request while the
+	// circuit breaker of the host is open.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+
+	// ErrResponseTooLarge is returned for a response body larger than
+	// ClientConfig.MaxResponseBytes. The host did answer so it is neither
+	// retried nor counted as a failure by the circuit breaker.
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+// ResponseError is returned for responses with a non 2xx status. The body is
+// kept so callers can inspect the error sent by the server.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Error implements the error interface.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("[%v] request to [%v] failed with status [%v]: %s", e.Method, e.URL, e.StatusCode, truncate(e.Body, 256))
+}
+
+// Response is a response read in full.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// ClientConfig describes how a Client sends requests. Zero values take the
+// defaults listed.
+type ClientConfig struct {
+
+	// Timeout bounds each attempt, 10s by default.
+	Timeout time.Duration
+
+	// MaxRetries is how often an idempotent request is retried after a
+	// network error or a 408, 429, 500, 502, 503 or 504, 2 by default. A
+	// negative value disables retries.
+	MaxRetries int
+
+	// BackoffBase and BackoffMax bound the exponential backoff between
+	// retries, 100ms and 2s by default. The wait is jittered between zero
+	// and the backoff, a longer Retry-After is honoured up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+
+	// BreakerFailures consecutive failures of a host open its circuit for
+	// BreakerCooldown, 5 and 30s by default. Failures are network errors
+	// and 5xx responses. After the cooldown one request is let through to
+	// probe the host. A negative BreakerFailures disables the breaker.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// MaxResponseBytes limits the response body read, 10MB by default.
+	MaxResponseBytes int64
+
+	// Header is sent with every request.
+	Header http.Header
+
+	// Transport sends the requests, http.DefaultTransport by default.
+	Transport http.RoundTripper
+}
+
+// Client is an HTTP client for calling other services. It is safe for
+// concurrent use and meant to be reused.
+type Client struct {
+	cfg    ClientConfig
+	http   *http.Client
+	tracer trace.Tracer
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewClient returns a Client configured by cfg.
+func NewClient(cfg ClientConfig) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = 100 * time.Millisecond
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = 2 * time.Second
+	}
+	if cfg.BreakerFailures == 0 {
+		cfg.BreakerFailures = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = 10 << 20
+	}
+
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{Transport: cfg.Transport},
+		tracer:   otel.Tracer(tracerName),
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Do sends a request and reads the response. A non nil body is sent as JSON
+// unless it is a []byte, which is sent as is. header is added to the
+// Client's Header.
+//
+// Idempotent methods, and requests carrying an Idempotency-Key, are retried.
+// Responses with a non 2xx status are returned as a *ResponseError.
+func (c *Client) Do(ctx context.Context, method string, rawURL string, body interface{}, header http.Header) (_ *Response, err error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing url [%v]", rawURL)
+	}
+
+	// Encode the body once, every attempt sends a copy
+	var payload []byte
+	var contentType string
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload = b
+	default:
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding request body")
+		}
+		contentType = "application/json"
+	}
+
+	// Start the client span and record the outcome when done
+	ctx, span := c.tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("server.address", u.Host),
+			attribute.String("url.full", u.Redacted()),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	retries := 0
+	if isIdempotent(method) || header.Get("Idempotency-Key") != "" {
+		retries = c.cfg.MaxRetries
+	}
+	br := c.breaker(u.Host)
+
+	var resp *Response
+	for attempt := 0; ; attempt++ {
+
+		// A retry stopped by the breaker reports the last failure instead
+		if !br.allow(c.cfg) {
+			if attempt > 0 {
+				return result(method, u, resp, err)
+			}
+			return nil, errors.Wrapf(ErrCircuitOpen, "host [%v]", u.Host)
+		}
+
+		var retryAfter time.Duration
+		resp, retryAfter, err = c.attempt(ctx, method, u, payload, contentType, header)
+		if ctx.Err() != nil || errors.Is(err, ErrResponseTooLarge) {
+			br.abandon()
+		} else {
+			br.record(c.cfg, err == nil && resp.StatusCode < http.StatusInternalServerError)
+		}
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+
+		// Return unless the attempt can and should be retried
+		if attempt >= retries || !retryable(ctx, resp, err) {
+			span.SetAttributes(attribute.Int("http.request.resend_count", attempt))
+			return result(method, u, resp, err)
+		}
+
+		// Back off before the next attempt
+		wait := c.backoff(attempt, retryAfter)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt sends one request and reads the response, returning the
+// Retry-After delay the server asked for.
+func (c *Client) attempt(ctx context.Context, method string, u *url.URL, payload []byte, contentType string, header http.Header) (*Response, time.Duration, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, 0, err
+	}
+	for key, values := range c.cfg.Header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "reading response body")
+	}
+	if int64(len(data)) > c.cfg.MaxResponseBytes {
+		return nil, 0, errors.Wrapf(ErrResponseTooLarge, "limit of [%v] bytes", c.cfg.MaxResponseBytes)
+	}
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, retryAfter, nil
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given either in
+// seconds or as an HTTP-date relative to now. Invalid and past values are
+// zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	t, err := http.ParseTime(value)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
+
+// result returns the outcome of the last attempt, a non 2xx response is
+// returned as a *ResponseError.
+func result(method string, u *url.URL, resp *Response, err error) (*Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &ResponseError{
+			Method:     method,
+			URL:        u.Redacted(),
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       resp.Body,
+		}
+	}
+	return resp, nil
+}
+
+// backoff returns the jittered exponential wait before the retry following
+// attempt.
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	max := c.cfg.BackoffBase << attempt
+	if max > c.cfg.BackoffMax || max <= 0 {
+		max = c.cfg.BackoffMax
+	}
+	wait := time.Duration(rand.Int63n(int64(max) + 1))
+	if retryAfter > wait {
+		wait = retryAfter
+		if wait > c.cfg.BackoffMax {
+			wait = c.cfg.BackoffMax
+		}
+	}
+	return wait
+}
+
+// breaker returns the circuit breaker of host.
+func (c *Client) breaker(host string) *breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	br, ok := c.breakers[host]
+	if !ok {
+		br = &breaker{}
+		c.breakers[host] = br
+	}
+	return br
+}
+
+// isIdempotent reports whether requests with method can be repeated safely.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable reports whether an attempt failed in a way a retry may fix.
+func retryable(ctx context.Context, resp *Response, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// breaker is the circuit breaker of one host.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may be sent. Once the cooldown of an open
+// circuit has passed a single probe is allowed.
+func (b *breaker) allow(cfg ClientConfig) bool {
+	if cfg.BreakerFailures < 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < cfg.BreakerFailures {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < cfg.BreakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of a request, success closes the circuit.
+func (b *breaker) record(cfg ClientConfig, ok bool) {
+	if cfg.BreakerFailures < 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= cfg.BreakerFailures {
+		b.openedAt = time.Now()
+	}
+}
+
+// abandon ends a request without counting it, one cancelled by the caller
+// or with a response too large to read. The next request may probe again.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// truncate returns at most n bytes of b for error messages.
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
@@ -0,0 +1,259 @@
+package web_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// server counts the requests it receives and answers each with the status
+// returned by status for the request's number, starting at 1.
+func server(t *testing.T, status func(n int32) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status(calls.Add(1)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		status []int
+		calls  int32
+		want   int
+	}{
+		{"idempotent until success", http.MethodGet, nil, []int{503, 502, 200}, 3, 200},
+		{"idempotent until retries run out", http.MethodPut, nil, []int{500, 500, 500, 200}, 3, 500},
+		{"client error", http.MethodGet, nil, []int{404, 200}, 1, 404},
+		{"too many requests", http.MethodDelete, nil, []int{429, 204}, 2, 204},
+		{"not idempotent", http.MethodPost, nil, []int{503, 200}, 1, 503},
+		{"idempotency key", http.MethodPost, http.Header{"Idempotency-Key": {"k"}}, []int{503, 200}, 2, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := server(t, func(n int32) int { return tt.status[n-1] })
+			c := web.NewClient(web.ClientConfig{BackoffBase: time.Millisecond, BackoffMax: time.Millisecond, BreakerFailures: -1})
+
+			resp, err := c.Do(context.Background(), tt.method, srv.URL, nil, tt.header)
+			status := 0
+			var re *web.ResponseError
+			switch {
+			case errors.As(err, &re):
+				status = re.StatusCode
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			default:
+				status = resp.StatusCode
+			}
+			if status != tt.want || calls.Load() != tt.calls {
+				t.Fatalf("got status %v after %v calls, want %v after %v", status, calls.Load(), tt.want, tt.calls)
+			}
+		})
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min        time.Duration
+		max        time.Duration
+	}{
+		{"jittered", "", 0, 150 * time.Millisecond},
+		{"retry after seconds", "1", 250 * time.Millisecond, time.Second},
+		{"retry after date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 250 * time.Millisecond, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer srv.Close()
+
+			// Retry-After is honoured up to BackoffMax
+			c := web.NewClient(web.ClientConfig{BackoffBase: time.Millisecond, BackoffMax: 300 * time.Millisecond})
+			start := time.Now()
+			if _, err := c.Do(context.Background(), http.MethodGet, srv.URL, nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Fatalf("retried after %v, want between %v and %v", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestClientResponseTooLarge(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	c := web.NewClient(web.ClientConfig{MaxResponseBytes: 10, BreakerFailures: 1, BackoffBase: time.Millisecond})
+	for i := 0; i < 3; i++ {
+		if _, err := c.Do(context.Background(), http.MethodGet, srv.URL, nil, nil); !errors.Is(err, web.ErrResponseTooLarge) {
+			t.Fatalf("request %v: got %v, want ErrResponseTooLarge", i, err)
+		}
+	}
+
+	// Every request reached the server once, none was retried or stopped
+	// by the breaker
+	if calls.Load() != 3 {
+		t.Fatalf("server got %v requests, want 3", calls.Load())
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	srv, calls := server(t, func(n int32) int {
+		if failing.Load() {
+			return http.StatusInternalServerError
+		}
+		return http.StatusOK
+	})
+
+	const cooldown = 50 * time.Millisecond
+	c := web.NewClient(web.ClientConfig{MaxRetries: -1, BreakerFailures: 2, BreakerCooldown: cooldown})
+	ctx := context.Background()
+
+	// Consecutive failures open the circuit
+	for i := 0; i < 2; i++ {
+		c.Do(ctx, http.MethodGet, srv.URL, nil, nil)
+	}
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); !errors.Is(err, web.ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server got %v requests while open, want 2", calls.Load())
+	}
+
+	// A failed probe after the cooldown opens it again
+	time.Sleep(cooldown)
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); errors.Is(err, web.ErrCircuitOpen) {
+		t.Fatal("probe was not let through after the cooldown")
+	}
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); !errors.Is(err, web.ErrCircuitOpen) {
+		t.Fatalf("got %v after a failed probe, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it
+	time.Sleep(cooldown)
+	failing.Store(false)
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); err != nil {
+		t.Fatalf("closed circuit failed: %v", err)
+	}
+}
+
+func TestClientBreakerSingleProbe(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	release := make(chan struct{})
+	probing := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		probing <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	const cooldown = 20 * time.Millisecond
+	c := web.NewClient(web.ClientConfig{MaxRetries: -1, BreakerFailures: 1, BreakerCooldown: cooldown})
+	ctx := context.Background()
+	c.Do(ctx, http.MethodGet, srv.URL, nil, nil)
+	time.Sleep(cooldown)
+	failing.Store(false)
+
+	// While the probe is in flight other requests are refused
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil)
+		done <- err
+	}()
+	<-probing
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); !errors.Is(err, web.ErrCircuitOpen) {
+		t.Fatalf("got %v during the probe, want ErrCircuitOpen", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+}
+
+func TestClientBreakerAbandon(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	srv, calls := server(t, func(n int32) int {
+		if failing.Load() {
+			return http.StatusInternalServerError
+		}
+		return http.StatusOK
+	})
+
+	const cooldown = 20 * time.Millisecond
+	c := web.NewClient(web.ClientConfig{MaxRetries: -1, BreakerFailures: 1, BreakerCooldown: cooldown})
+	c.Do(context.Background(), http.MethodGet, srv.URL, nil, nil)
+	time.Sleep(cooldown)
+
+	// A probe cancelled by the caller is neither a failure nor a success
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Do(ctx, http.MethodGet, srv.URL, nil, nil); errors.Is(err, web.ErrCircuitOpen) {
+		t.Fatal("probe was not let through after the cooldown")
+	}
+
+	// So the next request may probe again
+	failing.Store(false)
+	if _, err := c.Do(context.Background(), http.MethodGet, srv.URL, nil, nil); err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("server got %v requests, want 2", calls.Load())
+	}
+}
+
+func TestDoRequestSingleAttempt(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			srv, calls := server(t, func(n int32) int { return http.StatusServiceUnavailable })
+			for i := 0; i < 6; i++ {
+				if _, err := web.DoRequest(srv.URL, nil, method, nil); err == nil {
+					t.Fatal("got no error for a 503")
+				}
+			}
+
+			// Neither retried nor stopped by a breaker after 5 failures
+			if calls.Load() != 6 {
+				t.Fatalf("got %v calls for 6 requests, want 6", calls.Load())
+			}
+		})
+	}
+}
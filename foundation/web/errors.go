@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"net/http"
+	"sync"
 )
 
 // Error is used to pass an error during the request through the application
@@ -20,12 +21,94 @@ type ErrorResponse struct {
 	Fields []FieldError `json:"Fields,omitempty"`
 }
 
+// ProblemDetails is the RFC 7807 form used for API responses from failures
+// when the App is configured with ErrorFormatProblem. Fields is an extension
+// member carrying the same field errors as ErrorResponse.
+type ProblemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Fields   []FieldError `json:"fields,omitempty"`
+}
+
+// ErrorFormat selects the body RespondError sends for failures.
+type ErrorFormat int
+
+// Set of error formats.
+const (
+	// ErrorFormatLegacy responds with an ErrorResponse.
+	ErrorFormatLegacy ErrorFormat = iota
+
+	// ErrorFormatProblem responds with application/problem+json
+	// ProblemDetails.
+	ErrorFormatProblem
+)
+
 // FieldError is used to indicate an error with a specific request field.
 type FieldError struct {
 	Field string `json:"Field"`
 	Error string `json:"Error"`
 }
 
+// ErrorMapper translates an application error into an *Error. It returns
+// nil when it does not recognise the error.
+type ErrorMapper func(err error) *Error
+
+// ErrorMap is a registry of ErrorMappers used by mid.Errors to give errors
+// from the business layer, which knows nothing about HTTP, a status code.
+// Mappers are tried in the order they were registered.
+type ErrorMap struct {
+	mu      sync.RWMutex
+	mappers []ErrorMapper
+}
+
+// NewErrorMap returns an empty ErrorMap.
+func NewErrorMap() *ErrorMap {
+	return &ErrorMap{}
+}
+
+// Register maps every error matching target with errors.Is, including
+// wrapped errors, to the provided status code.
+func (m *ErrorMap) Register(target error, statusCode int) {
+	m.RegisterFunc(func(err error) *Error {
+		if errors.Is(err, target) {
+			return &Error{err, statusCode, nil}
+		}
+		return nil
+	})
+}
+
+// RegisterFunc adds a custom ErrorMapper, typically one using errors.As to
+// extract details from a typed error.
+func (m *ErrorMap) RegisterFunc(mapper ErrorMapper) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappers = append(m.mappers, mapper)
+}
+
+// Map returns err translated by the first matching ErrorMapper. Errors that
+// already wrap an *Error, or that no mapper recognises, are returned as is.
+func (m *ErrorMap) Map(err error) error {
+	if m == nil {
+		return err
+	}
+	var webErr *Error
+	if errors.As(err, &webErr) {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, mapper := range m.mappers {
+		if webErr := mapper(err); webErr != nil {
+			return webErr
+		}
+	}
+	return err
+}
+
 // shutdown is a type used to help with the graceful termination of the service.
 type shutdown struct {
 	Message string
@@ -37,6 +120,12 @@ func (err *Error) Error() string {
 	return err.Err.Error()
 }
 
+// Unwrap returns the wrapped error so errors.Is and errors.As see through an
+// *Error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
 // Error is the implementation of the error interface.
 func (s *shutdown) Error() string {
 	return s.Message
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// mountMethods are the methods routed to a mounted http.Handler. OPTIONS is
+// left to the App so preflights are answered as for any other route.
+var mountMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Group registers routes under a path prefix with middleware of their own,
+// which runs after the App's general middleware and before the route's.
+type Group struct {
+	app    *App
+	prefix string
+	mw     []Middleware
+}
+
+// Group returns a Group registering routes under prefix, for example /v1.
+func (a *App) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{app: a, prefix: cleanPrefix(prefix), mw: mw}
+}
+
+// Group returns a nested Group under the prefix of g, its middleware runs
+// after the middleware of g.
+func (g *Group) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{
+		app:    g.app,
+		prefix: g.prefix + cleanPrefix(prefix),
+		mw:     append(g.middleware(), mw...),
+	}
+}
+
+// Handle sets a handler function for a given HTTP method and path, relative
+// to the Group's prefix, to the application server mux. Like the mux it
+// panics when path does not start with a slash.
+func (g *Group) Handle(
+	method string,
+	path string,
+	handler Handler,
+	mw ...Middleware,
+) *Route {
+	if !strings.HasPrefix(path, "/") {
+		panic("path [" + path + "] must start with a slash")
+	}
+	return g.app.Handle(method, g.prefix+path, handler, append(g.middleware(), mw...)...)
+}
+
+// Mount serves h for every path under prefix, relative to the Group's prefix.
+func (g *Group) Mount(prefix string, h http.Handler, mw ...Middleware) {
+	g.app.Mount(g.prefix+cleanPrefix(prefix), h, append(g.middleware(), mw...)...)
+}
+
+// middleware returns a copy of the Group's middleware safe to append to.
+func (g *Group) middleware() []Middleware {
+	mw := make([]Middleware, len(g.mw), len(g.mw)+1)
+	copy(mw, g.mw)
+	return mw
+}
+
+// Mount serves h, for example an http.FileServer or a third party handler,
+// for every path under prefix. The prefix is stripped from the request path
+// before h sees it. The request context carries the Values and the App's
+// general middleware and mw run as for any route.
+func (a *App) Mount(prefix string, h http.Handler, mw ...Middleware) {
+	prefix = cleanPrefix(prefix)
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		v, ok := ctx.Value(KeyValues).(*Values)
+		if !ok {
+			return NewShutdownError("web value missing from context")
+		}
+
+		// Strip the prefix as http.StripPrefix does.
+		r2 := r.Clone(ctx)
+		r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		r2.URL.RawPath = ""
+
+		// Record the status for the request logger middleware.
+		sw := statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(&sw, r2)
+		v.StatusCode = sw.status
+		return nil
+	}
+
+	// The catch all does not match the prefix itself so it is registered
+	// as well.
+	for _, method := range mountMethods {
+		a.Handle(method, prefix+"/", handler, mw...).Mounted = true
+		a.Handle(method, prefix+"/*path", handler, mw...).Mounted = true
+	}
+}
+
+// statusWriter records the status code written to a ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+// WriteHeader records the first status code.
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wrote {
+		sw.status, sw.wrote = status, true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the response as started with the default status.
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wrote = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the writer.
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers such as websocket upgrades take over the connection.
+// The response is recorded as switching protocols.
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	sw.status, sw.wrote = http.StatusSwitchingProtocols, true
+	return conn, rw, nil
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// cleanPrefix returns prefix with a leading slash and without a trailing
+// one, the root prefix is empty.
+func cleanPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
@@ -0,0 +1,162 @@
+package web_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// recorder returns middleware appending name to calls when it runs.
+func recorder(calls *[]string, name string) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			*calls = append(*calls, name)
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// status returns middleware storing the status recorded for the request.
+func status(code *int) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			err := handler(ctx, w, r)
+			*code = ctx.Value(web.KeyValues).(*web.Values).StatusCode
+			return err
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var calls []string
+	app := web.NewApp(make(chan os.Signal, 1), recorder(&calls, "app"))
+	noContent := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	v1 := app.Group("/v1/", recorder(&calls, "v1"))
+	v1.Group("admin", recorder(&calls, "admin")).Handle(http.MethodGet, "/users", noContent, recorder(&calls, "route"))
+	v1.Group("/public/", recorder(&calls, "public")).Handle(http.MethodGet, "/users", noContent, recorder(&calls, "route"))
+	v1.Handle(http.MethodGet, "/", noContent)
+
+	tests := []struct {
+		path  string
+		calls []string
+	}{
+		{"/v1/admin/users", []string{"app", "v1", "admin", "route"}},
+		{"/v1/public/users", []string{"app", "v1", "public", "route"}},
+		{"/v1/", []string{"app", "v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = nil
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("got status %v, want %v", w.Code, http.StatusNoContent)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Fatalf("middleware ran as %v, want %v", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestGroupPathWithoutSlash(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a path without a leading slash was registered")
+		}
+	}()
+	app := web.NewApp(make(chan os.Signal, 1))
+	app.Group("/v1").Handle(http.MethodGet, "users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+}
+
+func TestMount(t *testing.T) {
+	var calls []string
+	var code int
+	app := web.NewApp(make(chan os.Signal, 1), recorder(&calls, "app"))
+	files := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, r.URL.Path)
+	})
+	app.Group("/v1", recorder(&calls, "v1")).Mount("/static/", files, status(&code))
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/v1/static/", http.StatusOK, "/"},
+		{"/v1/static/css/site.css", http.StatusOK, "/css/site.css"},
+		{"/v1/static/missing", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls, code = nil, 0
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Fatalf("got %v [%v], want %v [%v]", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			if code != tt.status {
+				t.Fatalf("recorded status %v, want %v", code, tt.status)
+			}
+			if want := []string{"app", "v1"}; !reflect.DeepEqual(calls, want) {
+				t.Fatalf("middleware ran as %v, want %v", calls, want)
+			}
+		})
+	}
+}
+
+func TestMountHijack(t *testing.T) {
+	codes := make(chan int, 1)
+	app := web.NewApp(make(chan os.Signal, 1))
+	app.Mount("/ws", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("mounted handler cannot hijack the connection")
+			return
+		}
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			t.Errorf("hijacking: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		rw.Flush()
+	}), func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			err := handler(ctx, w, r)
+			codes <- ctx.Value(web.KeyValues).(*web.Values).StatusCode
+			return err
+		}
+	})
+
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ws/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("got body [%s] %v, want [ok]", body, err)
+	}
+	if code := <-codes; code != http.StatusSwitchingProtocols {
+		t.Fatalf("recorded status %v, want %v", code, http.StatusSwitchingProtocols)
+	}
+}
@@ -0,0 +1,242 @@
+package web
+
+import (
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/pkg/errors"
+	"gopkg.in/go-playground/validator.v9"
+	entranslations "gopkg.in/go-playground/validator.v9/translations/en"
+	frtranslations "gopkg.in/go-playground/validator.v9/translations/fr"
+	pttranslations "gopkg.in/go-playground/validator.v9/translations/pt_BR"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// registerTranslations creates the translator of every supported locale and
+// registers their validation messages. English is the fallback.
+//
+// The validator ships messages for en, fr and pt_BR. The pt_BR messages are
+// registered for pt as well so every other Portuguese variant, such as pt-PT,
+// falls back to them through its base language. The de and es messages below
+// cover the common tags, any other tag falls back to English.
+func registerTranslations(v *validator.Validate) (*ut.UniversalTranslator, error) {
+
+	enLocale := en.New()
+	uni := ut.New(enLocale, enLocale, fr.New(), de.New(), es.New(), pt.New(), pt_BR.New())
+
+	locales := []struct {
+		locale   string
+		register func(*validator.Validate, ut.Translator) error
+	}{
+		{"en", entranslations.RegisterDefaultTranslations},
+		{"fr", frtranslations.RegisterDefaultTranslations},
+		{"pt", pttranslations.RegisterDefaultTranslations},
+		{"pt_BR", pttranslations.RegisterDefaultTranslations},
+		{"de", deMessages.register},
+		{"es", esMessages.register},
+	}
+	for _, l := range locales {
+		trans, found := uni.GetTranslator(l.locale)
+		if !found {
+			return nil, errors.Errorf("locale [%v] is not supported", l.locale)
+		}
+		if err := l.register(v, trans); err != nil {
+			return nil, errors.Wrapf(err, "registering [%v] messages", l.locale)
+		}
+	}
+
+	return uni, nil
+}
+
+// negotiate returns the translator best matching the Accept-Language header
+// of r, or English.
+func negotiate(r *http.Request) ut.Translator {
+
+	type tag struct {
+		locale string
+		q      float64
+	}
+
+	// Parse the language ranges and their weights, dropping the ones the
+	// client refuses.
+	var tags []tag
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if q > 0 {
+			tags = append(tags, tag{locale, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	// Try each range as given, then its base language.
+	var locales []string
+	for _, t := range tags {
+		locale := strings.ReplaceAll(t.locale, "-", "_")
+		base, region, ok := strings.Cut(locale, "_")
+		if ok {
+			locales = append(locales, strings.ToLower(base)+"_"+strings.ToUpper(region))
+		}
+		locales = append(locales, strings.ToLower(base))
+	}
+	trans, _ := translator.FindTranslator(locales...)
+	return trans
+}
+
+// translate returns the message of verror in the language of trans, or in
+// English when trans has no message for the tag.
+func translate(verror validator.FieldError, trans ut.Translator) string {
+	msg := verror.Translate(trans)
+	if msg == verror.(error).Error() {
+		msg = verror.Translate(translator.GetFallback())
+	}
+	return msg
+}
+
+// messages are the validation messages of a locale keyed by tag. {0} is the
+// field and {1} the tag's parameter. Tags whose meaning depends on the kind
+// of field have a -string, -items and -number variant.
+type messages map[string]string
+
+// kinded lists the tags with -string, -items and -number variants.
+var kinded = []string{"len", "min", "max", "lt", "lte", "gt", "gte"}
+
+// register adds the messages to trans and registers them with v.
+func (m messages) register(v *validator.Validate, trans ut.Translator) error {
+	for key, text := range m {
+		if err := trans.Add(key, text, false); err != nil {
+			return err
+		}
+	}
+
+	noop := func(ut.Translator) error { return nil }
+	for key := range m {
+		if strings.Contains(key, "-") {
+			continue
+		}
+		if err := v.RegisterTranslation(key, trans, noop, translateMessage); err != nil {
+			return err
+		}
+	}
+	for _, tag := range kinded {
+		if err := v.RegisterTranslation(tag, trans, noop, translateKinded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateMessage translates tags with a single message.
+func translateMessage(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.(error).Error()
+	}
+	return msg
+}
+
+// translateKinded translates tags whose message depends on the kind of field.
+func translateKinded(trans ut.Translator, fe validator.FieldError) string {
+	variant := "-number"
+	switch fe.Kind() {
+	case reflect.String:
+		variant = "-string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		variant = "-items"
+	}
+	msg, err := trans.T(fe.Tag()+variant, fe.Field(), fe.Param())
+	if err != nil {
+		return fe.(error).Error()
+	}
+	return msg
+}
+
+// deMessages are the German validation messages.
+var deMessages = messages{
+	"required":      "{0} ist ein Pflichtfeld",
+	"len-string":    "{0} muss genau {1} Zeichen lang sein",
+	"len-items":     "{0} muss genau {1} Elemente enthalten",
+	"len-number":    "{0} muss gleich {1} sein",
+	"min-string":    "{0} muss mindestens {1} Zeichen lang sein",
+	"min-items":     "{0} muss mindestens {1} Elemente enthalten",
+	"min-number":    "{0} muss mindestens {1} sein",
+	"max-string":    "{0} darf höchstens {1} Zeichen lang sein",
+	"max-items":     "{0} darf höchstens {1} Elemente enthalten",
+	"max-number":    "{0} darf höchstens {1} sein",
+	"lt-string":     "{0} muss weniger als {1} Zeichen lang sein",
+	"lt-items":      "{0} muss weniger als {1} Elemente enthalten",
+	"lt-number":     "{0} muss kleiner als {1} sein",
+	"lte-string":    "{0} darf höchstens {1} Zeichen lang sein",
+	"lte-items":     "{0} darf höchstens {1} Elemente enthalten",
+	"lte-number":    "{0} muss kleiner oder gleich {1} sein",
+	"gt-string":     "{0} muss mehr als {1} Zeichen lang sein",
+	"gt-items":      "{0} muss mehr als {1} Elemente enthalten",
+	"gt-number":     "{0} muss größer als {1} sein",
+	"gte-string":    "{0} muss mindestens {1} Zeichen lang sein",
+	"gte-items":     "{0} muss mindestens {1} Elemente enthalten",
+	"gte-number":    "{0} muss größer oder gleich {1} sein",
+	"eq":            "{0} ist nicht gleich {1}",
+	"ne":            "{0} darf nicht gleich {1} sein",
+	"oneof":         "{0} muss einer der folgenden Werte sein: [{1}]",
+	"email":         "{0} muss eine gültige E-Mail-Adresse sein",
+	"url":           "{0} muss eine gültige URL sein",
+	"uuid":          "{0} muss eine gültige UUID sein",
+	"alpha":         "{0} darf nur Buchstaben enthalten",
+	"alphanum":      "{0} darf nur Buchstaben und Ziffern enthalten",
+	"numeric":       "{0} muss ein gültiger numerischer Wert sein",
+	"required_with": "{0} ist ein Pflichtfeld, wenn {1} vorhanden ist",
+}
+
+// esMessages are the Spanish validation messages.
+var esMessages = messages{
+	"required":      "{0} es un campo obligatorio",
+	"len-string":    "{0} debe tener exactamente {1} caracteres",
+	"len-items":     "{0} debe contener exactamente {1} elementos",
+	"len-number":    "{0} debe ser igual a {1}",
+	"min-string":    "{0} debe tener al menos {1} caracteres",
+	"min-items":     "{0} debe contener al menos {1} elementos",
+	"min-number":    "{0} debe ser {1} o más",
+	"max-string":    "{0} debe tener como máximo {1} caracteres",
+	"max-items":     "{0} debe contener como máximo {1} elementos",
+	"max-number":    "{0} debe ser {1} o menos",
+	"lt-string":     "{0} debe tener menos de {1} caracteres",
+	"lt-items":      "{0} debe contener menos de {1} elementos",
+	"lt-number":     "{0} debe ser menor que {1}",
+	"lte-string":    "{0} debe tener como máximo {1} caracteres",
+	"lte-items":     "{0} debe contener como máximo {1} elementos",
+	"lte-number":    "{0} debe ser menor o igual que {1}",
+	"gt-string":     "{0} debe tener más de {1} caracteres",
+	"gt-items":      "{0} debe contener más de {1} elementos",
+	"gt-number":     "{0} debe ser mayor que {1}",
+	"gte-string":    "{0} debe tener al menos {1} caracteres",
+	"gte-items":     "{0} debe contener al menos {1} elementos",
+	"gte-number":    "{0} debe ser mayor o igual que {1}",
+	"eq":            "{0} no es igual a {1}",
+	"ne":            "{0} no debe ser igual a {1}",
+	"oneof":         "{0} debe ser uno de [{1}]",
+	"email":         "{0} debe ser una dirección de correo electrónico válida",
+	"url":           "{0} debe ser una URL válida",
+	"uuid":          "{0} debe ser un UUID válido",
+	"alpha":         "{0} solo puede contener letras",
+	"alphanum":      "{0} solo puede contener letras y números",
+	"numeric":       "{0} debe ser un valor numérico válido",
+	"required_with": "{0} es obligatorio cuando {1} está presente",
+}
@@ -0,0 +1,52 @@
+package web_test
+
+import (
+	"dev/yourservice.git/foundation/web"
+	"reflect"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	type named struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"", "name is a required field"},
+		{"pt", "name é um campo requerido"},
+		{"pt-BR", "name é um campo requerido"},
+		{"pt-PT", "name é um campo requerido"},
+		{"PT-ao", "name é um campo requerido"},
+		{"fr-CA", "name est un champ obligatoire"},
+		{"de", "name ist ein Pflichtfeld"},
+		{"xx", "name is a required field"},
+		{"xx-YY, *", "name is a required field"},
+		{"pt;q=0, es", "name es un campo obligatorio"},
+		{"pt-PT;q=0.5, de;q=0.8", "name ist ein Pflichtfeld"},
+		{"xx, pt-PT;q=0.1", "name é um campo requerido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			var n named
+			got := decode(t, `{}`, tt.lang, &n)
+			want := []web.FieldError{{Field: "name", Error: tt.want}}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("fields %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRegisterTranslationPortuguese(t *testing.T) {
+	for _, locale := range []string{"pt", "pt_BR"} {
+		if err := web.RegisterTranslation("daterange", locale, "{0} deve ser posterior a {1}"); err != nil {
+			t.Fatalf("registering [%v]: %v", locale, err)
+		}
+	}
+	if err := web.RegisterTranslation("daterange", "pt_PT", "{0} deve ser posterior a {1}"); err == nil {
+		t.Fatal("registered a translation for a locale Decode does not negotiate")
+	}
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/openapi"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Doc describes a route in the App's OpenAPI document. Request, Query and
+// Response are values of the types decoded from the body, read from the query
+// string and sent back, typically their zero values. Query fields are named by
+// their query or json tags. Schemas follow the json and validate tags.
+type Doc struct {
+	Summary     string   `json:"Summary,omitempty"`
+	Description string   `json:"Description,omitempty"`
+	Tags        []string `json:"Tags,omitempty"`
+
+	Request  interface{} `json:"-"`
+	Query    interface{} `json:"-"`
+	Response interface{} `json:"-"`
+
+	// Status is the status of a successful response, 200 by default.
+	Status int `json:"Status,omitempty"`
+}
+
+// Describe documents the route in the App's OpenAPI document.
+//
+//	app.Handle(http.MethodGet, "/entities/:id", getEntity).Describe(web.Doc{
+//		Summary:  "Get an entity",
+//		Response: Entity{},
+//	})
+func (r *Route) Describe(doc Doc) *Route {
+	r.Doc = &doc
+	return r
+}
+
+// OpenAPI returns the OpenAPI document of the routes registered on the App.
+// Debug, OPTIONS and mounted routes are left out, routes without a Doc are
+// listed with their path parameters only. Failures are documented as the
+// body of the App's ErrorFormat.
+func (a *App) OpenAPI(info openapi.Info) *openapi.Document {
+	doc := openapi.Document{
+		OpenAPI: openapi.Version,
+		Info:    info,
+		Paths:   make(map[string]*openapi.PathItem),
+	}
+	g := openapi.NewGenerator(&doc)
+
+	errType, errBody := "application/json", interface{}(ErrorResponse{})
+	if a.errorFormat == ErrorFormatProblem {
+		errType, errBody = "application/problem+json", ProblemDetails{}
+	}
+	failure := &openapi.Response{
+		Description: "Error",
+		Content:     map[string]openapi.MediaType{errType: {Schema: g.Schema(errBody)}},
+	}
+
+	for _, route := range a.routes {
+		if route.Debug || route.Mounted || route.Method == http.MethodOptions {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+		op := openapi.Operation{Responses: map[string]*openapi.Response{"default": failure}}
+		for _, name := range params {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: openapi.Types{"string"}},
+			})
+		}
+
+		status := http.StatusOK
+		success := &openapi.Response{}
+		if d := route.Doc; d != nil {
+			op.Summary, op.Description, op.Tags = d.Summary, d.Description, d.Tags
+			if d.Query != nil {
+				op.Parameters = append(op.Parameters, g.Parameters(d.Query, "query")...)
+			}
+			if d.Request != nil {
+				op.RequestBody = &openapi.RequestBody{
+					Required: true,
+					Content:  map[string]openapi.MediaType{"application/json": {Schema: g.Schema(d.Request)}},
+				}
+			}
+			if d.Response != nil {
+				success.Content = map[string]openapi.MediaType{"application/json": {Schema: g.Schema(d.Response)}}
+			}
+			if d.Status != 0 {
+				status = d.Status
+			}
+		}
+		success.Description = http.StatusText(status)
+		op.Responses[strconv.Itoa(status)] = success
+
+		item, exists := doc.Paths[path]
+		if !exists {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		item.SetOperation(route.Method, &op)
+	}
+
+	return &doc
+}
+
+// OpenAPIHandler serves the App's OpenAPI document, built on each request so
+// it includes routes registered after the handler.
+func (a *App) OpenAPIHandler(info openapi.Info) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return Respond(ctx, w, a.OpenAPI(info), http.StatusOK)
+	}
+}
+
+// openAPIPath converts a route path such as /entities/:id into the OpenAPI
+// template /entities/{id} and returns the names of its parameters.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
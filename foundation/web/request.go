@@ -4,14 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/dimfeld/httptreemux"
-	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
-	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
 	"gopkg.in/go-playground/validator.v9"
-	translations "gopkg.in/go-playground/validator.v9/translations/en"
-	"io/ioutil"
+	"io"
+	"mime"
 	"net/http"
 	"reflect"
 	"strings"
@@ -28,16 +27,13 @@ func init() {
 	// Instantiate the validator for use.
 	validate = validator.New()
 
-	// Instantiate the english locale for the validator library.
-	enLocale := en.New()
-
-	// Create a value using English as the fallback locale (first argument).
-	// Provide one or more arguments for additional supported locales.
-	translator = ut.New(enLocale, enLocale)
-
-	// Register the english error messages for validation errors.
-	lang, _ := translator.GetTranslator("en")
-	_ = translations.RegisterDefaultTranslations(validate, lang)
+	// Register the error messages of every supported locale, English is
+	// the fallback. The messages are fixed so a failure is a bug.
+	var err error
+	translator, err = registerTranslations(validate)
+	if err != nil {
+		panic(err)
+	}
 
 	// Use JSON tag names for errors instead of Go struct names.
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -57,101 +53,222 @@ func Params(r *http.Request) map[string]string {
 // Decode reads the body of an HTTP request looking for a JSON document. The
 // body is decoded into the provided value.
 //
-// If the provided value is a struct then it is checked for validation tags.
+// The request must have an application/json Content-Type and the body must
+// hold exactly one JSON document within the App's body limit. Malformed
+// documents are reported with the offset and field path of the problem.
+//
+// Strings anywhere in the value are sanitized as described by Sanitize. If
+// the provided value is a struct, or a slice of structs for batch requests,
+// then it is checked for validation tags.
 func Decode(r *http.Request, dst interface{}) error {
 
+	// Only accept JSON
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !isJSON(mediaType) {
+		err := errors.Errorf("Content-Type must be application/json")
+		return NewRequestError(err, http.StatusUnsupportedMediaType)
+	}
+
 	// Decode body into struct interface{}
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	// Anything after the document is a mistake, not a second document
+	end := decoder.InputOffset()
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return decodeError(err)
+		}
+		err := errors.Errorf("body must contain a single JSON document, found data after offset %d", end)
 		return NewRequestError(err, http.StatusBadRequest)
 	}
 
-	// Sanitize all string fields dynamically
-	v := reflect.ValueOf(dst).Elem()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i).Interface()
-		switch field.(type) {
-		case string:
-			// Sanitize field
-			fieldStr := field.(string)
-			p := bluemonday.UGCPolicy()
-			fieldStr = p.Sanitize(fieldStr)
-			v.Field(i).SetString(fieldStr)
+	// Sanitize all string values, however deeply nested
+	Sanitize(dst)
+
+	// Validate the decoded struct, or each struct of a decoded batch, with
+	// messages in the language negotiated from Accept-Language.
+	lang := negotiate(r)
+	if fields := validateValue(reflect.ValueOf(dst), "", lang); len(fields) > 0 {
+		return &Error{
+			Err:        errors.New("field validation error"),
+			StatusCode: http.StatusBadRequest,
+			Fields:     fields,
+		}
+	}
+
+	return nil
+}
+
+// validateValue validates v when it is a struct, or each struct element when
+// it is a slice or array, and returns the failures. Field paths are prefixed
+// with prefix and the element index, messages are in the language of lang.
+func validateValue(v reflect.Value, prefix string, lang ut.Translator) []FieldError {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
 		}
+		v = v.Elem()
 	}
 
-	// Validate decoded struct
-	if err := validate.Struct(dst); err != nil {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var fields []FieldError
+		for i := 0; i < v.Len(); i++ {
+			fields = append(fields, validateValue(v.Index(i), fmt.Sprintf("%s[%d].", prefix, i), lang)...)
+		}
+		return fields
+
+	case reflect.Struct:
+		err := validate.Struct(v.Interface())
+		if err == nil {
+			return nil
+		}
 
 		// Use a type assertion to get the real error value.
 		verrors, ok := err.(validator.ValidationErrors)
 		if !ok {
-			return err
+			return []FieldError{{Field: strings.TrimSuffix(prefix, "."), Error: err.Error()}}
 		}
 
-		// lang controls the language of the error messages. You could look at
-		// the Accept-Language header if you intend to support multiple
-		// languages.
-		lang, _ := translator.GetTranslator("en")
-
 		var fields []FieldError
 		for _, verror := range verrors {
+
+			// The namespace starts with the struct name, nested fields keep
+			// their path.
+			path := verror.Namespace()
+			if i := strings.Index(path, "."); i >= 0 {
+				path = path[i+1:]
+			}
 			field := FieldError{
-				Field: verror.Field(),
-				Error: verror.Translate(lang),
+				Field: prefix + path,
+				Error: translate(verror, lang),
 			}
 			fields = append(fields, field)
 		}
+		return fields
+	}
+
+	return nil
+}
+
+// isJSON reports whether the media type is application/json or a
+// +json structured syntax suffix type.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// decodeError converts an error of the JSON decoder into an *Error that says
+// where the document is wrong without exposing the decoder's wording.
+func decodeError(err error) error {
 
+	var mbe *http.MaxBytesError
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &mbe):
+		err := errors.Errorf("body must not be larger than %d bytes", mbe.Limit)
+		return NewRequestError(err, http.StatusRequestEntityTooLarge)
+
+	case err == io.EOF:
+		return NewRequestError(errors.New("body must not be empty"), http.StatusBadRequest)
+
+	case err == io.ErrUnexpectedEOF:
+		return NewRequestError(errors.New("body contains incomplete JSON"), http.StatusBadRequest)
+
+	case errors.As(err, &syntax):
+		err := errors.Errorf("body contains malformed JSON at offset %d", syntax.Offset)
+		return NewRequestError(err, http.StatusBadRequest)
+
+	case errors.As(err, &typ):
+		field := typ.Field
+		if field == "" {
+			field = "(body)"
+		}
+		msg := fmt.Sprintf("must be %s, got %s at offset %d", jsonType(typ.Type), typ.Value, typ.Offset)
 		return &Error{
-			Err:        errors.New("field validation error"),
+			Err:        errors.Errorf("field [%v] %v", field, msg),
 			StatusCode: http.StatusBadRequest,
-			Fields:     fields,
+			Fields:     []FieldError{{Field: field, Error: msg}},
+		}
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &Error{
+			Err:        errors.Errorf("field [%v] is not allowed", field),
+			StatusCode: http.StatusBadRequest,
+			Fields:     []FieldError{{Field: field, Error: "unknown field"}},
 		}
 	}
 
-	return nil
+	return NewRequestError(errors.New("body could not be decoded"), http.StatusBadRequest)
 }
 
+// jsonType names the JSON type expected for a Go type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
+
+// defaultClient sends the requests of DoRequest. It makes a single attempt
+// without a circuit breaker, as DoRequest always has.
+var defaultClient = NewClient(ClientConfig{MaxRetries: -1, BreakerFailures: -1})
+
 // DoRequest handles sending a basic HTTP request to any URL
 // and get a response as []byte
+//
+// Requests are sent once, they are neither retried nor stopped by a circuit
+// breaker. A Client retries idempotent requests and opens its breaker for
+// failing hosts.
+//
+// Deprecated: use a Client, which takes a context and exposes the response
+// of failed requests.
 func DoRequest(url string, headers map[string]string, httpMethod string, data interface{}) ([]byte, error) {
+	return DoRequestContext(context.Background(), url, headers, httpMethod, data)
+}
 
-	// Create the http request
-	// Encode the data and set its content type in the case of an http POST
-	var req *http.Request
-	var err error
-	if httpMethod == http.MethodPost {
-		req, err = http.NewRequest(httpMethod, url, Encode(data))
-	} else if httpMethod == http.MethodGet {
-		req, err = http.NewRequest(httpMethod, url, nil)
-	} else {
-		err = errors.Errorf("unrecognized httpMethod [%v]", httpMethod)
-	}
-	if err != nil {
-		return nil, err
-	}
+// DoRequestContext is DoRequest within a client span that is a child of the
+// span in ctx. The trace is propagated to the receiver in the request
+// headers.
+//
+// Deprecated: use a Client, which exposes the response of failed requests.
+func DoRequestContext(ctx context.Context, url string, headers map[string]string, httpMethod string, data interface{}) ([]byte, error) {
+
+	header := make(http.Header, len(headers))
 	for key, value := range headers {
-		req.Header.Set(key, value)
+		header.Set(key, value)
 	}
 
-	// Attempt to do http request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	// Only a POST sends the data, as it always has
+	var body interface{}
+	if httpMethod == http.MethodPost {
+		body = data
 	}
-	defer resp.Body.Close()
 
-	// If not StatusOK, return error
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, errors.Errorf("failed to send [%v] request to [%v]", httpMethod, url)
+	resp, err := defaultClient.Do(ctx, httpMethod, url, body, header)
+	if err != nil {
+		return nil, err
 	}
-
-	// Return success
-	bytes, err := ioutil.ReadAll(resp.Body)
-	return bytes, nil
+	return resp.Body, nil
 
 }
 
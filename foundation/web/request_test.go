@@ -0,0 +1,82 @@
+package web_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	type entity struct {
+		Name string `json:"name"`
+	}
+	decode := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var e entity
+		if err := web.Decode(r, &e); err != nil {
+			return web.RespondError(ctx, w, err)
+		}
+		return web.Respond(ctx, w, e, http.StatusOK)
+	}
+
+	app := web.NewApp(make(chan os.Signal, 1))
+	app.SetMaxBodyBytes(32)
+	app.Handle(http.MethodPost, "/entities", decode)
+	app.Handle(http.MethodPost, "/small", decode, web.MaxBodyBytes(16))
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+		message     string
+	}{
+		{"valid", "/entities", "application/json", `{"name":"a"}`, http.StatusOK, ""},
+		{"charset", "/entities", "application/json; charset=utf-8", `{"name":"a"}`, http.StatusOK, ""},
+		{"json suffix", "/entities", "application/merge-patch+json", `{"name":"a"}`, http.StatusOK, ""},
+		{"trailing whitespace", "/entities", "application/json", "{\"name\":\"a\"}\n\t ", http.StatusOK, ""},
+		{"no content type", "/entities", "", `{"name":"a"}`, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		{"wrong content type", "/entities", "text/plain", `{"name":"a"}`, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		{"malformed content type", "/entities", "application/json;;", `{"name":"a"}`, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		{"too large", "/entities", "application/json", `{"name":"` + strings.Repeat("a", 32) + `"}`, http.StatusRequestEntityTooLarge, "body must not be larger than 32 bytes"},
+		{"too large trailing data", "/entities", "application/json", `{"name":"a"}` + strings.Repeat(" ", 32) + `x`, http.StatusRequestEntityTooLarge, "body must not be larger than 32 bytes"},
+		{"route limit", "/small", "application/json", `{"name":"aaaaaaaaaa"}`, http.StatusRequestEntityTooLarge, "body must not be larger than 16 bytes"},
+		{"within route limit", "/small", "application/json", `{"name":"a"}`, http.StatusOK, ""},
+		{"second document", "/entities", "application/json", `{"name":"a"} {"name":"b"}`, http.StatusBadRequest, "body must contain a single JSON document, found data after offset 12"},
+		{"trailing garbage", "/entities", "application/json", `{"name":"a"}x`, http.StatusBadRequest, "body must contain a single JSON document, found data after offset 12"},
+		{"empty", "/entities", "application/json", ``, http.StatusBadRequest, "body must not be empty"},
+		{"incomplete", "/entities", "application/json", `{"name":`, http.StatusBadRequest, "body contains incomplete JSON"},
+		{"malformed", "/entities", "application/json", `{"name":}`, http.StatusBadRequest, "body contains malformed JSON at offset 9"},
+		{"wrong type", "/entities", "application/json", `{"name":1}`, http.StatusBadRequest, "field [name] must be a string, got number at offset 9"},
+		{"unknown field", "/entities", "application/json", `{"other":"a"}`, http.StatusBadRequest, "field [other] is not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("got status %v, want %v: %s", w.Code, tt.status, w.Body.Bytes())
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+			var er web.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &er); err != nil {
+				t.Fatalf("decoding error response [%s]: %v", w.Body.Bytes(), err)
+			}
+			if er.Error != tt.message {
+				t.Fatalf("got message [%v], want [%v]", er.Error, tt.message)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -16,6 +16,18 @@ func Respond(
 	data interface{},
 	statusCode int,
 ) error {
+	return respond(ctx, w, data, statusCode, "application/json; charset=utf-8")
+}
+
+// respond converts a Go value to JSON and sends it to the client with the
+// provided content type.
+func respond(
+	ctx context.Context,
+	w http.ResponseWriter,
+	data interface{},
+	statusCode int,
+	contentType string,
+) error {
 
 	// Set the status code for the request logger middleware. If the context is
 	// missing this value, request the service to be shutdown gracefully.
@@ -37,16 +49,12 @@ func Respond(
 		return err
 	}
 
-	// Set the content type and headers once we know marshaling has succeeded.
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, OPTIONS, DELETE")
-	w.Header().Set("Access-Control-Allow-Headers", "content-type, authorization, disbursetotalcount, disbursetotalsum, uidx")
+	// Set the content type once we know marshaling has succeeded.
+	w.Header().Set("Content-Type", contentType)
 
 	// Write the status code to the response.
 	w.WriteHeader(statusCode)
-	resp := string(jsonData)
-	log.Print(resp)
+	slog.DebugContext(ctx, "response", "status", statusCode, "bytes", len(jsonData))
 
 	// Send the result back to the client.
 	_, err = w.Write(jsonData)
@@ -60,6 +68,9 @@ func Respond(
 // RespondError sends an error reponse back to the client. Should not be called
 // by a handler functions unless for specific business reasons. Generally,
 // errors should be handled by middleware.
+//
+// The body is an ErrorResponse or ProblemDetails depending on the App's
+// ErrorFormat.
 func RespondError(
 	ctx context.Context,
 	w http.ResponseWriter,
@@ -68,26 +79,40 @@ func RespondError(
 
 	// If the error was of the type *Error, the handler has
 	// a specific status code and error to return.
-	webErr, ok := errors.Cause(err).(*Error)
-	if ok {
+	// If not, the handler sent any arbitrary error value so use 500.
+	statusCode := http.StatusInternalServerError
+	message := http.StatusText(http.StatusInternalServerError)
+	var fields []FieldError
+	var webErr *Error
+	if errors.As(err, &webErr) {
+		statusCode = webErr.StatusCode
+		message = webErr.Err.Error()
+		fields = webErr.Fields
+	}
+
+	// Respond in the legacy format unless the App asked for problem details.
+	format, _ := ctx.Value(keyErrorFormat).(ErrorFormat)
+	if format != ErrorFormatProblem {
 		er := ErrorResponse{
-			Error:  webErr.Err.Error(),
-			Fields: webErr.Fields,
-		}
-		if err := Respond(ctx, w, er, webErr.StatusCode); err != nil {
-			return err
+			Error:  message,
+			Fields: fields,
 		}
-		return nil
+		return Respond(ctx, w, er, statusCode)
 	}
 
-	// If not, the handler sent any arbitrary error value so use 500.
-	err_ := ErrorResponse{
-		Error: http.StatusText(http.StatusInternalServerError),
+	// The trace id identifies this occurrence of the problem.
+	pd := ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Fields: fields,
 	}
-	err = Respond(ctx, w, err_, http.StatusInternalServerError)
-	if err != nil {
-		return err
+	if message != pd.Title {
+		pd.Detail = message
 	}
-	return nil
+	if v, ok := ctx.Value(KeyValues).(*Values); ok {
+		pd.Instance = v.TraceID
+	}
+	return respond(ctx, w, pd, statusCode, "application/problem+json")
 
 }
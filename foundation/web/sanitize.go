@@ -0,0 +1,145 @@
+package web
+
+import (
+	"github.com/microcosm-cc/bluemonday"
+	"reflect"
+	"sync"
+)
+
+// Sanitization policies selected with the sanitize struct tag. Policies are
+// safe for concurrent use once built.
+var (
+	// strictPolicy removes all HTML.
+	strictPolicy = bluemonday.StrictPolicy()
+
+	// ugcPolicy keeps the HTML that is safe in user generated content. It
+	// is the default.
+	ugcPolicy = bluemonday.UGCPolicy()
+)
+
+// checkedTypes holds the types whose sanitize tags were checked.
+var checkedTypes sync.Map
+
+// Sanitize removes unsafe HTML from every string reachable from v, walking
+// nested structs, pointers, interfaces, slices, arrays and map values. v must
+// be a pointer for the strings to be replaced.
+//
+// Struct fields choose their policy with a tag, nested values inherit it:
+//
+//	Name string `sanitize:"strict"` // remove all HTML
+//	Body string `sanitize:"ugc"`    // keep safe HTML, the default
+//	Hash string `sanitize:"none"`   // leave the value untouched
+//
+// Any other tag value is a programming error and panics the first time the
+// type is sanitized, whatever the value holds, so a misspelled tag does not
+// silently keep the inherited policy.
+func Sanitize(v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() {
+		if _, checked := checkedTypes.Load(rv.Type()); !checked {
+			checkTags(rv.Type(), make(map[reflect.Type]bool))
+			checkedTypes.Store(rv.Type(), true)
+		}
+	}
+	sanitize(rv, ugcPolicy)
+}
+
+// checkTags panics when a struct reachable from t has an unknown sanitize
+// tag. Types held by interfaces are checked when they are sanitized.
+func checkTags(t reflect.Type, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		checkTags(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			fieldPolicy(t.Field(i), nil)
+			checkTags(t.Field(i).Type, seen)
+		}
+	}
+}
+
+// sanitize sanitizes the strings of v with policy, a nil policy leaves them
+// untouched.
+func sanitize(v reflect.Value, policy *bluemonday.Policy) {
+	if policy == nil {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			sanitize(v.Elem(), policy)
+		}
+
+	case reflect.Interface:
+		// The value held by an interface can not be set in place so a copy
+		// is sanitized and stored back.
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		elem := v.Elem()
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		sanitize(cp, policy)
+		v.Set(cp)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			sanitize(v.Field(i), fieldPolicy(t.Field(i), policy))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			sanitize(v.Index(i), policy)
+		}
+
+	case reflect.Map:
+		// Map values are not addressable, sanitize copies and store them
+		// back under the same key.
+		if v.IsNil() {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			cp := reflect.New(iter.Value().Type()).Elem()
+			cp.Set(iter.Value())
+			sanitize(cp, policy)
+			v.SetMapIndex(iter.Key(), cp)
+		}
+
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(policy.Sanitize(v.String()))
+		}
+	}
+}
+
+// fieldPolicy returns the policy selected by the field's sanitize tag or the
+// inherited policy when the tag is absent. It panics on an unknown tag.
+func fieldPolicy(f reflect.StructField, inherited *bluemonday.Policy) *bluemonday.Policy {
+	tag, ok := f.Tag.Lookup("sanitize")
+	if !ok {
+		return inherited
+	}
+	switch tag {
+	case "strict":
+		return strictPolicy
+	case "ugc":
+		return ugcPolicy
+	case "none":
+		return nil
+	}
+	panic("sanitize tag [" + tag + "] of field [" + f.Name + "] must be one of strict, ugc or none")
+}
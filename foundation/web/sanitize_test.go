@@ -0,0 +1,111 @@
+package web_test
+
+import (
+	"dev/yourservice.git/foundation/web"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Markup sanitized by the tests and what each policy leaves of it.
+const (
+	markup = `<b>bold</b><script>alert(1)</script>`
+	ugc    = `<b>bold</b>`
+	strict = `bold`
+)
+
+// comment is nested in the values sanitized by the tests.
+type comment struct {
+	Body   string
+	Author string `sanitize:"strict"`
+	Raw    string `sanitize:"none"`
+}
+
+// post holds every kind of value Sanitize walks.
+type post struct {
+	Title    string `sanitize:"strict"`
+	Body     string
+	Comment  comment
+	Reply    *comment
+	Comments []comment
+	Tags     map[string]string `sanitize:"strict"`
+	Authors  map[string]comment
+	Extra    interface{}
+	Raw      struct {
+		Text   string
+		Strict string `sanitize:"strict"`
+	} `sanitize:"none"`
+	Archived *post `sanitize:"strict"`
+}
+
+func TestSanitize(t *testing.T) {
+	c := func() comment { return comment{Body: markup, Author: markup, Raw: markup} }
+	reply := c()
+	p := post{
+		Title:    markup,
+		Body:     markup,
+		Comment:  c(),
+		Reply:    &reply,
+		Comments: []comment{c(), c()},
+		Tags:     map[string]string{"a": markup},
+		Authors:  map[string]comment{"a": c()},
+		Extra:    map[string]interface{}{"list": []interface{}{markup}},
+		Archived: &post{Body: markup, Comment: c()},
+	}
+	p.Raw.Text, p.Raw.Strict = markup, markup
+	web.Sanitize(&p)
+
+	sanitized := comment{Body: ugc, Author: strict, Raw: markup}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"strict field", p.Title, strict},
+		{"default policy", p.Body, ugc},
+		{"nested struct", p.Comment, sanitized},
+		{"pointer", *p.Reply, sanitized},
+		{"slice", p.Comments, []comment{sanitized, sanitized}},
+		{"map of strings", p.Tags, map[string]string{"a": strict}},
+		{"map of structs", p.Authors, map[string]comment{"a": sanitized}},
+		{"interface", p.Extra, map[string]interface{}{"list": []interface{}{ugc}}},
+		{"none inherited", p.Raw.Text, markup},
+		{"none covers nested tags", p.Raw.Strict, markup},
+		{"strict inherited", p.Archived.Body, strict},
+		{"own tags under strict", p.Archived.Comment, comment{Body: strict, Author: strict, Raw: markup}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Fatalf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeSlice(t *testing.T) {
+	batch := []comment{{Body: markup, Author: markup}}
+	web.Sanitize(&batch)
+	if batch[0].Body != ugc || batch[0].Author != strict {
+		t.Fatalf("got %+v", batch[0])
+	}
+}
+
+func TestSanitizeUnknownTag(t *testing.T) {
+	type misspelled struct {
+		Name string `sanitize:"strcit"`
+	}
+	type parent struct {
+		Child *misspelled
+	}
+
+	// The type is rejected even when the tagged field is never reached
+	defer func() {
+		r := recover()
+		if msg, _ := r.(string); !strings.Contains(msg, "strcit") {
+			t.Fatalf("got panic %v, want one naming the tag", r)
+		}
+	}()
+	web.Sanitize(&parent{})
+	t.Fatal("sanitized a value with an unknown tag")
+}
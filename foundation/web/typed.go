@@ -0,0 +1,201 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Get sends a GET request and decodes the JSON response into a T.
+func Get[T any](ctx context.Context, c *Client, url string, header http.Header) (T, error) {
+	return Call[struct{}, T](ctx, c, http.MethodGet, url, nil, header)
+}
+
+// Post sends req as JSON in a POST request and decodes the JSON response
+// into a Resp.
+func Post[Req, Resp any](ctx context.Context, c *Client, url string, req Req, header http.Header) (Resp, error) {
+	return Call[Req, Resp](ctx, c, http.MethodPost, url, &req, header)
+}
+
+// Put sends req as JSON in a PUT request and decodes the JSON response into
+// a Resp.
+func Put[Req, Resp any](ctx context.Context, c *Client, url string, req Req, header http.Header) (Resp, error) {
+	return Call[Req, Resp](ctx, c, http.MethodPut, url, &req, header)
+}
+
+// Patch sends req as JSON in a PATCH request and decodes the JSON response
+// into a Resp.
+func Patch[Req, Resp any](ctx context.Context, c *Client, url string, req Req, header http.Header) (Resp, error) {
+	return Call[Req, Resp](ctx, c, http.MethodPatch, url, &req, header)
+}
+
+// Delete sends a DELETE request, any response body is discarded.
+func Delete(ctx context.Context, c *Client, url string, header http.Header) error {
+	_, err := Call[struct{}, struct{}](ctx, c, http.MethodDelete, url, nil, header)
+	return err
+}
+
+// Call sends req as JSON, or no body when req is nil, and decodes the JSON
+// response into a Resp. An empty response leaves Resp at its zero value.
+//
+// Failures answered with an ErrorResponse or ProblemDetails, as services
+// built on this package do, are returned as an *Error carrying the status
+// and field errors. The *ResponseError stays available through errors.As.
+func Call[Req, Resp any](ctx context.Context, c *Client, method string, url string, req *Req, header http.Header) (Resp, error) {
+	var out Resp
+
+	var body interface{}
+	if req != nil {
+		body = req
+	}
+
+	resp, err := c.Do(ctx, method, url, body, header)
+	if err != nil {
+		return out, remoteError(err)
+	}
+
+	if len(resp.Body) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return out, errors.Wrapf(err, "decoding [%v] response from [%v]", method, url)
+	}
+	return out, nil
+}
+
+// responseError is the message of an ErrorResponse or ProblemDetails sent by
+// another service, it unwraps to the full response.
+type responseError struct {
+	msg  string
+	resp *ResponseError
+}
+
+// Error implements the error interface.
+func (e *responseError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the response the error was decoded from.
+func (e *responseError) Unwrap() error {
+	return e.resp
+}
+
+// remoteError converts a *ResponseError whose body is an ErrorResponse or
+// ProblemDetails into an *Error, other errors are returned unchanged.
+func remoteError(err error) error {
+	var re *ResponseError
+	if !errors.As(err, &re) || len(re.Body) == 0 {
+		return err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(re.Header.Get("Content-Type"))
+	if mediaType == "application/problem+json" {
+		var pd ProblemDetails
+		if json.Unmarshal(re.Body, &pd) != nil || pd.Title == "" {
+			return err
+		}
+		msg := pd.Detail
+		if msg == "" {
+			msg = pd.Title
+		}
+		return &Error{Err: &responseError{msg, re}, StatusCode: re.StatusCode, Fields: pd.Fields}
+	}
+
+	var er ErrorResponse
+	if json.Unmarshal(re.Body, &er) != nil || er.Error == "" {
+		return err
+	}
+	return &Error{Err: &responseError{er.Error, re}, StatusCode: re.StatusCode, Fields: er.Fields}
+}
+
+// Page is a page of a list endpoint, Next is the cursor of the following page
+// and empty on the last one.
+type Page[T any] struct {
+	Items []T    `json:"Items"`
+	Next  string `json:"Next,omitempty"`
+}
+
+// Pager iterates over the items of a list endpoint page by page, following
+// the after cursor.
+//
+//	p := web.Paginate[Entity](client, url, 100, nil)
+//	for p.Next(ctx) {
+//		e := p.Item()
+//	}
+//	if err := p.Err(); err != nil {
+//	}
+type Pager[T any] struct {
+	client *Client
+	url    string
+	header http.Header
+	limit  int
+
+	after string
+	page  []T
+	i     int
+	last  bool
+	item  T
+	err   error
+}
+
+// Paginate returns a Pager over the list endpoint at url requesting limit
+// items per page, zero leaves the page size to the server.
+func Paginate[T any](c *Client, url string, limit int, header http.Header) *Pager[T] {
+	return &Pager[T]{client: c, url: url, header: header, limit: limit}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when there are no more items or a request failed.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	for p.i >= len(p.page) {
+		if p.last || p.err != nil {
+			return false
+		}
+		p.fetch(ctx)
+	}
+	p.item = p.page[p.i]
+	p.i++
+	return true
+}
+
+// Item returns the current item.
+func (p *Pager[T]) Item() T {
+	return p.item
+}
+
+// Err returns the error that stopped the iteration.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// fetch requests the page after the current cursor.
+func (p *Pager[T]) fetch(ctx context.Context) {
+
+	u, err := url.Parse(p.url)
+	if err != nil {
+		p.err = errors.Wrapf(err, "parsing url [%v]", p.url)
+		return
+	}
+	q := u.Query()
+	if p.after != "" {
+		q.Set("after", p.after)
+	}
+	if p.limit > 0 {
+		q.Set("limit", strconv.Itoa(p.limit))
+	}
+	u.RawQuery = q.Encode()
+
+	page, err := Get[Page[T]](ctx, p.client, u.String(), p.header)
+	if err != nil {
+		p.err = err
+		return
+	}
+	// A cursor that does not move would repeat the page forever
+	p.last = page.Next == "" || page.Next == p.after
+	p.page, p.i = page.Items, 0
+	p.after = page.Next
+}
@@ -0,0 +1,183 @@
+package web_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// newClient returns a client that neither retries nor opens its circuit, so
+// each call maps to exactly one request.
+func newClient() *web.Client {
+	return web.NewClient(web.ClientConfig{MaxRetries: -1, BreakerFailures: -1})
+}
+
+func TestRemoteError(t *testing.T) {
+	fields := []web.FieldError{{Field: "name", Error: "name is a required field"}}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		msg         string
+		fields      []web.FieldError
+		remote      bool
+	}{
+		{"error response", "application/json", `{"Error":"data validation error","Fields":[{"Field":"name","Error":"name is a required field"}]}`, "data validation error", fields, true},
+		{"problem detail", "application/problem+json", `{"type":"about:blank","title":"Bad Request","status":400,"detail":"data validation error","fields":[{"Field":"name","Error":"name is a required field"}]}`, "data validation error", fields, true},
+		{"problem title", "application/problem+json; charset=utf-8", `{"type":"about:blank","title":"Bad Request","status":400}`, "Bad Request", nil, true},
+		{"problem without title", "application/problem+json", `{"detail":"data validation error"}`, "", nil, false},
+		{"error response without message", "application/json", `{"Fields":[]}`, "", nil, false},
+		{"plain text", "text/plain", "bad request", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := web.Get[struct{}](context.Background(), newClient(), srv.URL, nil)
+
+			// The response stays reachable whatever the body
+			var re *web.ResponseError
+			if !errors.As(err, &re) || re.StatusCode != http.StatusBadRequest || string(re.Body) != tt.body {
+				t.Fatalf("got %v, want a *ResponseError with the body", err)
+			}
+
+			var we *web.Error
+			if errors.As(err, &we) != tt.remote {
+				t.Fatalf("got %T, want an *Error [%v]", err, tt.remote)
+			}
+			if !tt.remote {
+				return
+			}
+			if we.StatusCode != http.StatusBadRequest || we.Error() != tt.msg || !reflect.DeepEqual(we.Fields, tt.fields) {
+				t.Fatalf("got status %v message [%v] fields %v, want %v [%v] %v", we.StatusCode, we.Error(), we.Fields, http.StatusBadRequest, tt.msg, tt.fields)
+			}
+		})
+	}
+}
+
+func TestPager(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    map[string]web.Page[int]
+		want     []int
+		requests int
+	}{
+		{
+			name: "follows the cursor",
+			pages: map[string]web.Page[int]{
+				"":  {Items: []int{1, 2}, Next: "2"},
+				"2": {Items: []int{3, 4}, Next: "4"},
+				"4": {Items: []int{5}},
+			},
+			want:     []int{1, 2, 3, 4, 5},
+			requests: 3,
+		},
+		{
+			name: "skips empty pages",
+			pages: map[string]web.Page[int]{
+				"":  {Items: []int{}, Next: "a"},
+				"a": {Items: []int{1}, Next: "b"},
+				"b": {Items: []int{}, Next: "c"},
+				"c": {Items: []int{}},
+			},
+			want:     []int{1},
+			requests: 4,
+		},
+		{
+			name: "no items",
+			pages: map[string]web.Page[int]{
+				"": {Items: []int{}},
+			},
+			want:     nil,
+			requests: 1,
+		},
+		{
+			name: "stuck cursor",
+			pages: map[string]web.Page[int]{
+				"":  {Items: []int{1}, Next: "a"},
+				"a": {Items: []int{2}, Next: "a"},
+			},
+			want:     []int{1, 2},
+			requests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if limit := r.URL.Query().Get("limit"); limit != "2" {
+					t.Errorf("requested limit [%v], want 2", limit)
+				}
+				page, exists := tt.pages[r.URL.Query().Get("after")]
+				if !exists {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				json.NewEncoder(w).Encode(page)
+			}))
+			defer srv.Close()
+
+			var got []int
+			p := web.Paginate[int](newClient(), srv.URL, 2, nil)
+			for p.Next(context.Background()) {
+				got = append(got, p.Item())
+			}
+			if err := p.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || requests != tt.requests {
+				t.Fatalf("got %v in %v requests, want %v in %v", got, requests, tt.want, tt.requests)
+			}
+		})
+	}
+}
+
+func TestPagerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The url's own query is kept and no limit is sent
+		if q := r.URL.Query(); q.Get("sort") != "id" || q.Has("limit") {
+			t.Errorf("got query [%v]", r.URL.RawQuery)
+		}
+		if r.URL.Query().Get("after") == "" {
+			json.NewEncoder(w).Encode(web.Page[int]{Items: []int{1}, Next: "1"})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var got []int
+	p := web.Paginate[int](newClient(), srv.URL+"?sort=id", 0, nil)
+	for p.Next(context.Background()) {
+		got = append(got, p.Item())
+	}
+
+	// Items before the failure are still returned
+	var re *web.ResponseError
+	if !errors.As(p.Err(), &re) || re.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want the failed response", p.Err())
+	}
+	if len(got) != 1 {
+		t.Fatalf("got items %v, want [1]", got)
+	}
+
+	// The pager stays stopped
+	if p.Next(context.Background()) {
+		t.Fatal("Next returned true after an error")
+	}
+}
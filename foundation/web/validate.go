@@ -0,0 +1,67 @@
+package web
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	"github.com/pkg/errors"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// FieldLevel gives a ValidationFunc the field being validated.
+type FieldLevel = validator.FieldLevel
+
+// ValidationFunc reports whether the field is valid for a custom tag.
+type ValidationFunc = validator.Func
+
+// StructLevel gives a StructLevelFunc the struct being validated and reports
+// its errors with ReportError.
+type StructLevel = validator.StructLevel
+
+// StructLevelFunc validates rules spanning several fields of a struct.
+type StructLevelFunc = validator.StructLevelFunc
+
+// The Register functions change the validator shared by every App. They are
+// not safe to call while requests are decoded so call them during startup.
+
+// RegisterValidation adds the validation tag checked by fn to Decode.
+// messages holds the error message per locale, {0} is replaced by the field
+// and {1} by the tag's parameter. The English message is required, locales
+// without a message use it.
+func RegisterValidation(tag string, fn ValidationFunc, messages map[string]string) error {
+	if messages["en"] == "" {
+		return errors.Errorf("validation [%v] has no en message", tag)
+	}
+	if err := validate.RegisterValidation(tag, fn); err != nil {
+		return errors.Wrapf(err, "registering validation [%v]", tag)
+	}
+	for locale, message := range messages {
+		if err := RegisterTranslation(tag, locale, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterStructValidation runs fn whenever a struct of the type of one of
+// types is validated. Errors are reported with sl.ReportError using a tag
+// that has messages registered with RegisterTranslation.
+func RegisterStructValidation(fn StructLevelFunc, types ...interface{}) {
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterTranslation adds or replaces the message of tag in locale, {0} is
+// replaced by the field and {1} by the tag's parameter. The locale must be
+// one Decode negotiates: en, fr, de, es, pt or pt_BR.
+func RegisterTranslation(tag string, locale string, message string) error {
+	trans, found := translator.GetTranslator(locale)
+	if !found {
+		return errors.Errorf("unsupported locale [%v]", locale)
+	}
+
+	register := func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+	if err := validate.RegisterTranslation(tag, trans, register, translateMessage); err != nil {
+		return errors.Wrapf(err, "registering [%v] translation of [%v]", locale, tag)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package web_test
+
+import (
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// payment exercises custom field and struct level validation.
+type payment struct {
+	Currency string    `json:"currency" validate:"required,iso4217"`
+	Phone    string    `json:"phone" validate:"omitempty,msisdn"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+}
+
+// currencies is the subset of ISO 4217 used by the tests.
+var currencies = map[string]bool{"EUR": true, "USD": true, "ZAR": true}
+
+// msisdn matches E.164 phone numbers.
+var msisdn = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+func init() {
+	err := web.RegisterValidation("iso4217", func(fl web.FieldLevel) bool {
+		return currencies[fl.Field().String()]
+	}, map[string]string{
+		"en": "{0} must be an ISO 4217 currency code",
+		"fr": "{0} doit être un code de devise ISO 4217",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = web.RegisterValidation("msisdn", func(fl web.FieldLevel) bool {
+		return msisdn.MatchString(fl.Field().String())
+	}, map[string]string{
+		"en": "{0} must be an international phone number",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	web.RegisterStructValidation(func(sl web.StructLevel) {
+		p := sl.Current().Interface().(payment)
+		if !p.From.IsZero() && !p.To.IsZero() && !p.To.After(p.From) {
+			sl.ReportError(p.To, "to", "To", "daterange", "from")
+		}
+	}, payment{})
+	if err := web.RegisterTranslation("daterange", "en", "{0} must be after {1}"); err != nil {
+		panic(err)
+	}
+	if err := web.RegisterTranslation("daterange", "de", "{0} muss nach {1} liegen"); err != nil {
+		panic(err)
+	}
+}
+
+// decode runs web.Decode on body with the Accept-Language header lang.
+func decode(t *testing.T, body string, lang string, dst interface{}) []web.FieldError {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept-Language", lang)
+
+	err := web.Decode(r, dst)
+	if err == nil {
+		return nil
+	}
+	var webErr *web.Error
+	if !errors.As(err, &webErr) {
+		t.Fatalf("Decode returned %T %v, want *web.Error", err, err)
+	}
+	if webErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d", webErr.StatusCode, http.StatusBadRequest)
+	}
+	return webErr.Fields
+}
+
+func TestCustomValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		lang string
+		want []web.FieldError
+	}{
+		{
+			name: "valid",
+			body: `{"currency":"ZAR","phone":"+27821234567","from":"2024-01-01T00:00:00Z","to":"2024-02-01T00:00:00Z"}`,
+		},
+		{
+			name: "unknown currency",
+			body: `{"currency":"XYZ"}`,
+			want: []web.FieldError{{Field: "currency", Error: "currency must be an ISO 4217 currency code"}},
+		},
+		{
+			name: "translated message",
+			body: `{"currency":"XYZ"}`,
+			lang: "fr-FR,fr;q=0.9",
+			want: []web.FieldError{{Field: "currency", Error: "currency doit être un code de devise ISO 4217"}},
+		},
+		{
+			name: "english fallback",
+			body: `{"currency":"EUR","phone":"0821234567"}`,
+			lang: "de",
+			want: []web.FieldError{{Field: "phone", Error: "phone must be an international phone number"}},
+		},
+		{
+			name: "struct level",
+			body: `{"currency":"USD","from":"2024-02-01T00:00:00Z","to":"2024-01-01T00:00:00Z"}`,
+			want: []web.FieldError{{Field: "to", Error: "to must be after from"}},
+		},
+		{
+			name: "struct level translated",
+			body: `{"currency":"USD","from":"2024-02-01T00:00:00Z","to":"2024-01-01T00:00:00Z"}`,
+			lang: "de-CH",
+			want: []web.FieldError{{Field: "to", Error: "to muss nach from liegen"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p payment
+			got := decode(t, tt.body, tt.lang, &p)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomValidationBatch(t *testing.T) {
+	var batch []payment
+	got := decode(t, `[{"currency":"EUR"},{"currency":"ABC"}]`, "", &batch)
+
+	want := []web.FieldError{{Field: "[1].currency", Error: "currency must be an ISO 4217 currency code"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields %+v, want %+v", got, want)
+	}
+}
+
+func TestRegisterTranslationUnknownLocale(t *testing.T) {
+	if err := web.RegisterTranslation("iso4217", "xx", "{0}"); err == nil {
+		t.Error("RegisterTranslation accepted an unsupported locale")
+	}
+}
+
+func TestRegisterValidationRequiresEnglish(t *testing.T) {
+	err := web.RegisterValidation("noenglish", func(fl web.FieldLevel) bool {
+		return true
+	}, map[string]string{"fr": "{0} est invalide"})
+	if err == nil {
+		t.Error("RegisterValidation accepted messages without en")
+	}
+}
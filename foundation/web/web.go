@@ -3,10 +3,18 @@ package web
 
 import (
 	"context"
+	"encoding/hex"
 	"github.com/dimfeld/httptreemux"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -17,9 +25,24 @@ type ctxKey int64
 // KeyValues is how request values are stored/retrieved.
 const KeyValues ctxKey = 1
 
-// Values represent state for each request.
+// keyErrorFormat is how the App's ErrorFormat is stored/retrieved.
+const keyErrorFormat ctxKey = 2
+
+// keyBodyLimit is how the request's bodyLimit is stored/retrieved.
+const keyBodyLimit ctxKey = 3
+
+// DefaultMaxBodyBytes is the largest request body an App accepts unless
+// configured otherwise.
+const DefaultMaxBodyBytes = 1 << 20
+
+// tracerName identifies the spans created by this package.
+const tracerName = "dev/yourservice.git/foundation/web"
+
+// Values represent state for each request. TraceID is the 32 hex character
+// id of the request's trace and Route is the pattern the request matched.
 type Values struct {
 	TraceID    string
+	Route      string
 	Now        time.Time
 	StatusCode int
 }
@@ -36,16 +59,33 @@ type Handler func(
 // mux. This is a singleton and used by the standard library for metrics
 // and profiling. The application may want to add other handlers like
 // readiness and liveness to that mux. If this is not tracked, the routes
-// could try to be registered more than once, causing a panic.
+// could try to be registered more than once, causing a panic. The default mux
+// routes by path alone so only one method can be registered per debug path.
 var registered = make(map[string]bool)
 
+// Route describes a handler registered on an App. Mounted is set for the
+// routes of a handler added with Mount.
+type Route struct {
+	Method  string `json:"Method"`
+	Path    string `json:"Path"`
+	Debug   bool   `json:"Debug,omitempty"`
+	Mounted bool   `json:"Mounted,omitempty"`
+	Doc     *Doc   `json:"Doc,omitempty"`
+}
+
 // App is the entrypoint into our application and what configures our context
 // object for each of our http handlers. Feel free to add any configuration
 // data/logic on this App struct.
 type App struct {
-	mux      *httptreemux.ContextMux
-	shutdown chan os.Signal
-	mw       []Middleware
+	mux         *httptreemux.ContextMux
+	shutdown    chan os.Signal
+	mw          []Middleware
+	errorFormat ErrorFormat
+	maxBody     int64
+	tracer      trace.Tracer
+	routes      []*Route
+	methods     map[string][]string
+	options     map[string]http.HandlerFunc
 }
 
 // IsDevAppServer will return true if we are running locally
@@ -63,6 +103,10 @@ func NewApp(shutdown chan os.Signal, mw ...Middleware) *App {
 		mux:      mux,
 		shutdown: shutdown,
 		mw:       mw,
+		maxBody:  DefaultMaxBodyBytes,
+		tracer:   otel.Tracer(tracerName),
+		methods:  make(map[string][]string),
+		options:  make(map[string]http.HandlerFunc),
 	}
 }
 
@@ -72,62 +116,209 @@ func (a *App) SignalShutdown() {
 	a.shutdown <- syscall.SIGTERM
 }
 
+// SetErrorFormat selects the body sent by RespondError for requests handled
+// by this App. The default is ErrorFormatLegacy.
+func (a *App) SetErrorFormat(format ErrorFormat) {
+	a.errorFormat = format
+}
+
+// SetMaxBodyBytes limits the request bodies of the App's routes to n bytes.
+// Routes can override it with the MaxBodyBytes middleware. The default is
+// DefaultMaxBodyBytes.
+func (a *App) SetMaxBodyBytes(n int64) {
+	a.maxBody = n
+}
+
 // ServeHTTP implements the http.Handler interface. It's the entry point for all
-// http traffic and allows the opentelemetry mux to run first to handle tracing.
-// The opentelemetry mux then calls the application mux to handle application
-// traffic.
+// http traffic, tracing is started per route in handle so spans are named
+// after the route pattern.
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.mux.ServeHTTP(w, r)
 }
 
 // Handle sets a handler function for a given HTTP method and path pair to the
-// application server mux.
+// application server mux. The returned Route can be described for the App's
+// OpenAPI document.
 func (a *App) Handle(
 	method string,
 	path string,
 	handler Handler,
 	mw ...Middleware,
+) *Route {
+	return a.handle(false, method, path, handler, mw...)
+}
+
+// HandleDebug sets a handler function for a given HTTP method and path pair
+// to the default server mux, which is where net/http/pprof and expvar
+// register. The App's general middleware is not applied so debug traffic
+// stays out of the API logs and metrics. Errors returned by the handler are
+// logged and responded to.
+func (a *App) HandleDebug(
+	method string,
+	path string,
+	handler Handler,
+	mw ...Middleware,
 ) {
-	a.handle(false, method, path, handler, mw...)
+	a.handle(true, method, path, handler, mw...)
+}
+
+// Routes returns every route registered on the App in registration order.
+func (a *App) Routes() []Route {
+	routes := make([]Route, len(a.routes))
+	for i, route := range a.routes {
+		routes[i] = *route
+	}
+	return routes
 }
 
-// handle performs the real work of applying boilerplate and framework code for
-// a handler.
+// handle registers a handler on the App's mux or the default server mux.
+//
+// Every path registered on the App answers OPTIONS requests so CORS
+// preflights reach the App's general middleware. An OPTIONS handler
+// registered explicitly replaces the automatic one.
 func (a *App) handle(
 	debug bool,
 	method string,
 	path string,
 	handler Handler,
 	mw ...Middleware,
-) {
+) *Route {
 	if debug {
 		// Track all the handlers that are being registered so we don't have the
 		// same handlers registered twice to this singleton.
-		if _, exists := registered[method+path]; exists {
-			return
+		if _, exists := registered[path]; exists {
+			return nil
 		}
-		registered[method+path] = true
+		registered[path] = true
+	}
+	route := &Route{Method: method, Path: path, Debug: debug}
+	a.routes = append(a.routes, route)
+	h := a.wrap(debug, method, path, handler, mw...)
+
+	// The default mux does not route by method so check it here.
+	if debug {
+		http.DefaultServeMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				w.Header().Set("Allow", method)
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				return
+			}
+			h(w, r)
+		})
+		return route
+	}
+
+	// OPTIONS for a path is always dispatched through the App so the
+	// automatic preflight handler can be replaced.
+	_, seen := a.methods[path]
+	if !seen {
+		a.methods[path] = nil
+		preflight := a.wrap(false, http.MethodOptions, path, a.preflight(path))
+		a.mux.Handle(http.MethodOptions, path, func(w http.ResponseWriter, r *http.Request) {
+			if h, exists := a.options[path]; exists {
+				h(w, r)
+				return
+			}
+			preflight(w, r)
+		})
+	}
+	if method == http.MethodOptions {
+		a.options[path] = h
+		return route
 	}
+	a.methods[path] = append(a.methods[path], method)
+
+	a.mux.Handle(method, path, h)
+
+	return route
+}
+
+// preflight answers OPTIONS requests for path with the methods registered on
+// it. CORS headers are added by middleware.
+func (a *App) preflight(path string) Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		allow := append([]string{http.MethodOptions}, a.methods[path]...)
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		return Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// wrap applies the boilerplate and framework code for a handler, debug
+// handlers skip the App's general middleware.
+func (a *App) wrap(
+	debug bool,
+	method string,
+	path string,
+	handler Handler,
+	mw ...Middleware,
+) http.HandlerFunc {
 
 	// First wrap handler specific middleware around this handler.
 	handler = wrapMiddleware(mw, handler)
 
 	// Add the application's general middleware to the handler chain.
-	handler = wrapMiddleware(a.mw, handler)
+	if !debug {
+		handler = wrapMiddleware(a.mw, handler)
+	}
 
 	// The function to execute for each request.
 	h := func(w http.ResponseWriter, r *http.Request) {
 
+		// Continue the caller's trace from the traceparent or
+		// X-Cloud-Trace-Context headers and start the request span.
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := a.tracer.Start(ctx, method+" "+path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("http.route", path),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
 		// Set the context with the required values to
 		// process the request.
 		v := Values{
-			TraceID: uuid.New().String()[:8],
+			TraceID: traceID(span.SpanContext()),
+			Route:   path,
 			Now:     time.Now(),
 		}
-		ctx := context.WithValue(r.Context(), KeyValues, &v)
+		ctx = context.WithValue(ctx, KeyValues, &v)
+		ctx = context.WithValue(ctx, keyErrorFormat, a.errorFormat)
+
+		// Limit the body, debug handlers are trusted.
+		if !debug {
+			body := &bodyLimit{ReadCloser: r.Body, w: w, n: a.maxBody}
+			ctx = context.WithValue(ctx, keyBodyLimit, body)
+			r.Body = body
+		}
 
 		// Call the wrapped handler functions.
-		if err := handler(ctx, w, r); err != nil {
+		err := handler(ctx, w, r)
+		span.SetAttributes(attribute.Int("http.response.status_code", v.StatusCode))
+		if v.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(v.StatusCode))
+		}
+		if err != nil {
+			// Debug handlers run without the error middleware so their
+			// errors are logged and responded to here, only a shutdown error
+			// questions the integrity of the app.
+			if debug && !IsShutdown(err) {
+				slog.ErrorContext(ctx, "debug request failed",
+					"trace_id", v.TraceID,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"error", err.Error(),
+				)
+				if v.StatusCode == 0 {
+					if err := RespondError(ctx, w, err); err != nil {
+						slog.ErrorContext(ctx, "debug response failed", "trace_id", v.TraceID, "error", err.Error())
+					}
+				}
+				return
+			}
+
 			// If we get an error at this level we are way beyond handling it.
 			// If an error gets this far we have to shutdown the app, this is
 			// foundational code.
@@ -136,6 +327,16 @@ func (a *App) handle(
 		}
 	}
 
-	a.mux.Handle(method, path, h)
+	return h
 
 }
+
+// traceID returns the trace id of the span context. When no TracerProvider is
+// installed the span context is empty so a random id is used instead.
+func traceID(sc trace.SpanContext) string {
+	if sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	id := uuid.New()
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,31 @@
+package web_test
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDebugHandlerError(t *testing.T) {
+	shutdown := make(chan os.Signal, 1)
+	app := web.NewApp(shutdown)
+	app.HandleDebug(http.MethodGet, "/debug/failing", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.NewRequestError(errors.New("not ready"), http.StatusServiceUnavailable)
+	})
+
+	w := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/failing", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got status %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	select {
+	case <-shutdown:
+		t.Fatal("a debug handler error must not shut down the app")
+	default:
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"time"
+)
+
+type apikeys struct {
+	Service *apikey.Service
+}
+
+// keyResponse is the view of a key returned to admins, it never includes the
+// hash
+type keyResponse struct {
+	ID        string     `json:"ID"`
+	Name      string     `json:"Name"`
+	Scopes    []string   `json:"Scopes"`
+	Roles     []string   `json:"Roles"`
+	RateLimit int        `json:"RateLimit"`
+	CreatedAt time.Time  `json:"CreatedAt"`
+	RevokedAt *time.Time `json:"RevokedAt,omitempty"`
+}
+
+// issueResponse is returned once when a key is issued, with its secret
+type issueResponse struct {
+	Key    keyResponse `json:"Key"`
+	Secret string      `json:"Secret"`
+}
+
+// toKeyResponse converts a key to its admin view
+func toKeyResponse(k apikey.Key) keyResponse {
+	return keyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Scopes:    k.Scopes,
+		Roles:     k.Roles,
+		RateLimit: k.RateLimit,
+		CreatedAt: k.CreatedAt,
+		RevokedAt: k.RevokedAt,
+	}
+}
+
+// issue creates a key, the secret is only ever returned by this call
+func (a apikeys) issue(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Decode, sanitize & validate request
+	var nk apikey.NewKey
+	err := web.Decode(r, &nk)
+	if err != nil {
+		return err
+	}
+
+	// Issue
+	k, secret, err := a.Service.Issue(ctx, nk)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	response := issueResponse{
+		Key:    toKeyResponse(k),
+		Secret: secret,
+	}
+	return web.Respond(ctx, w, response, http.StatusCreated)
+
+}
+
+// list returns all keys without their secrets
+func (a apikeys) list(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// List
+	keys, err := a.Service.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	response := make([]keyResponse, 0, len(keys))
+	for _, k := range keys {
+		response = append(response, toKeyResponse(k))
+	}
+	return web.Respond(ctx, w, response, http.StatusOK)
+
+}
+
+// revoke stops the key identified by the :id path param from authenticating
+func (a apikeys) revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Revoke
+	err := a.Service.Revoke(ctx, web.Params(r)["id"])
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	// Register the expvar and pprof handlers on the default mux
+	_ "expvar"
+	_ "net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DebugMux registers the metrics, route listing and build info endpoints
+// next to pprof and expvar and returns the http.Handler served on the debug
+// listener. It is kept off the API listener so it is never exposed publicly.
+func DebugMux(app *web.App, build string, gatherer prometheus.Gatherer) http.Handler {
+
+	metrics := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+	app.HandleDebug(http.MethodGet, "/metrics", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		metrics.ServeHTTP(w, r)
+		return nil
+	})
+
+	// All routes registered on the App, including these debug routes
+	app.HandleDebug(http.MethodGet, "/debug/routes", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, app.Routes(), http.StatusOK)
+	})
+
+	// Build and module information embedded by the go tool
+	app.HandleDebug(http.MethodGet, "/debug/build", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		info := struct {
+			Build     string            `json:"Build"`
+			GoVersion string            `json:"GoVersion"`
+			Path      string            `json:"Path,omitempty"`
+			Settings  map[string]string `json:"Settings,omitempty"`
+			Deps      map[string]string `json:"Deps,omitempty"`
+		}{
+			Build:     build,
+			GoVersion: runtime.Version(),
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.Path = bi.Path
+			info.Settings = make(map[string]string)
+			for _, s := range bi.Settings {
+				info.Settings[s.Key] = s.Value
+			}
+			info.Deps = make(map[string]string)
+			for _, d := range bi.Deps {
+				info.Deps[d.Path] = d.Version
+			}
+		}
+		return web.Respond(ctx, w, info, http.StatusOK)
+	})
+
+	return http.DefaultServeMux
+
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/business/yourservice"
+	"dev/yourservice.git/foundation/web"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// errorMap translates the yourservice and apikey errors into HTTP status codes
+func errorMap() *web.ErrorMap {
+
+	errs := web.NewErrorMap()
+
+	// Business rule violations are reported against the offending field
+	errs.RegisterFunc(func(err error) *web.Error {
+		var verr *yourservice.ValidationError
+		if !errors.As(err, &verr) {
+			return nil
+		}
+		return &web.Error{
+			Err:        yourservice.ErrValidation,
+			StatusCode: http.StatusUnprocessableEntity,
+			Fields:     []web.FieldError{{Field: verr.Field, Error: verr.Reason}},
+		}
+	})
+
+	errs.Register(yourservice.ErrNotFound, http.StatusNotFound)
+	errs.Register(yourservice.ErrPrecondition, http.StatusPreconditionFailed)
+	errs.Register(yourservice.ErrConflict, http.StatusConflict)
+	errs.Register(yourservice.ErrValidation, http.StatusUnprocessableEntity)
+	errs.Register(yourservice.ErrUnauthorized, http.StatusUnauthorized)
+	errs.Register(apikey.ErrNotFound, http.StatusNotFound)
+	return errs
+
+}
@@ -1,48 +1,287 @@
 package handlers
 
 import (
+	"dev/yourservice.git/business/apikey"
 	"dev/yourservice.git/business/yourservice"
-	"dev/yourservice.git/business/i"
 	"dev/yourservice.git/business/mid"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/idempotency"
+	"dev/yourservice.git/foundation/logger"
+	"dev/yourservice.git/foundation/openapi"
+	"dev/yourservice.git/foundation/ratelimit"
 	"dev/yourservice.git/foundation/web"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Yourservice struct {
 	Service *yourservice.Service
 }
 
+// APIConfig holds the settings used to construct the API
+type APIConfig struct {
+	// Build is the version of the service reported in the OpenAPI
+	// document.
+	Build string
+
+	// ProblemDetails makes errors respond with RFC 7807 problem+json
+	// instead of the legacy ErrorResponse shape.
+	ProblemDetails bool
+
+	// MaxBodyBytes limits request bodies, zero keeps
+	// web.DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	// Registry receives the request metrics.
+	Registry prometheus.Registerer
+
+	// Auth validates the bearer tokens of the yourservice routes. When nil
+	// the routes are not authenticated.
+	Auth *auth.Auth
+
+	// Policy authorizes authenticated callers per route. When nil every
+	// authenticated caller is allowed.
+	Policy *auth.Policy
+
+	// APIKeys authenticates X-API-Key callers and backs the admin key
+	// endpoints. When nil API keys are not accepted.
+	APIKeys *apikey.Service
+
+	// CORS allows browsers on other origins to call the API. When nil no
+	// CORS headers are sent.
+	CORS *mid.CORSConfig
+
+	// RateLimits holds the rate limit state, a MemoryStore is used when nil.
+	RateLimits ratelimit.Store
+
+	// GlobalLimit applies to every request per client IP except the health
+	// checks.
+	GlobalLimit ratelimit.Limit
+
+	// ClientIPHeader is the header the client IP is read from when the
+	// service runs behind proxies, such as X-Forwarded-For. TrustedProxies
+	// is the number of proxies appending to it. When empty the IP of the
+	// connection is used.
+	ClientIPHeader string
+	TrustedProxies int
+
+	// RouteLimits apply per route and authenticated caller, keyed by method
+	// and route pattern.
+	RouteLimits map[string]ratelimit.Limit
+
+	// Idempotency holds the responses replayed for retried POST requests,
+	// a MemoryStore is used when nil.
+	Idempotency idempotency.Store
+
+	// IdempotencyTTL is how long responses are replayed, zero disables
+	// Idempotency-Key support.
+	IdempotencyTTL time.Duration
+
+	// OpenAPI validates the requests of the yourservice and admin routes
+	// against a spec written first. When nil requests are only validated
+	// by the handlers.
+	OpenAPI *mid.OpenAPIConfig
+}
+
 // API constructs a http.Handler with all application routes defined
-func API(log i.Logger, y Yourservice, shutdown chan os.Signal) *web.App {
+func API(log *slog.Logger, y Yourservice, shutdown chan os.Signal, cfg APIConfig) *web.App {
+
+	// CORS is optional, a nil middleware is skipped
+	var cors web.Middleware
+	if cfg.CORS != nil {
+		cors = mid.CORS(*cfg.CORS)
+	}
+
+	// Rate limits are optional, a disabled limit is skipped
+	if cfg.RateLimits == nil {
+		cfg.RateLimits = ratelimit.NewMemoryStore()
+	}
+	clientIP := mid.RateLimitByIP
+	if cfg.ClientIPHeader != "" {
+		clientIP = mid.RateLimitByForwardedIP(cfg.ClientIPHeader, cfg.TrustedProxies)
+	}
+	var globalLimit, routeLimit web.Middleware
+	if cfg.GlobalLimit.Enabled() {
+		globalLimit = mid.RateLimit(mid.RateLimitConfig{
+			Store: cfg.RateLimits,
+			Key:   clientIP,
+			Limit: cfg.GlobalLimit,
+			Exempt: map[string]bool{
+				"GET /readiness":  true,
+				"GET /liveliness": true,
+			},
+		})
+	}
+	if len(cfg.RouteLimits) > 0 {
+		routeLimit = mid.RateLimit(mid.RateLimitConfig{
+			Store:  cfg.RateLimits,
+			Key:    mid.RateLimitBySubject(clientIP),
+			Routes: cfg.RouteLimits,
+		})
+	}
 
 	// Create web app with middleware
 	app := web.NewApp(
 		shutdown,
 		mid.Logger(log),
-		mid.Errors(log),
+		mid.Metrics(cfg.Registry),
+		cors,
+		mid.Errors(log, errorMap()),
+		globalLimit,
 		mid.Panics(log),
 	)
+	if cfg.ProblemDetails {
+		app.SetErrorFormat(web.ErrorFormatProblem)
+	}
+	if cfg.MaxBodyBytes > 0 {
+		app.SetMaxBodyBytes(cfg.MaxBodyBytes)
+	}
 
 	// Check Service
 	ch := check{}
-	app.Handle(http.MethodGet, "/readiness", ch.readiness)
-	app.Handle(http.MethodGet, "/liveliness", ch.liveliness)
+	app.Handle(http.MethodGet, "/readiness", ch.readiness).Describe(web.Doc{
+		Summary: "Report whether the service is ready",
+		Tags:    []string{"checks"},
+	})
+	app.Handle(http.MethodGet, "/liveliness", ch.liveliness).Describe(web.Doc{
+		Summary: "Report the status and environment of the instance",
+		Tags:    []string{"checks"},
+	})
+
+	// OpenAPI document of the routes below
+	app.Handle(http.MethodGet, "/openapi.json", app.OpenAPIHandler(openapi.Info{
+		Title:   "yourservice",
+		Version: cfg.Build,
+	})).Describe(web.Doc{
+		Summary: "Get the OpenAPI document of the API",
+		Tags:    []string{"checks"},
+	})
+
+	// Authentication and authorization are optional, a nil middleware is
+	// skipped. An API key is checked before the bearer token and route
+	// limits count the authenticated caller. Without bearer authentication
+	// the API key is required.
+	var apiKey, authen, authz web.Middleware
+	if cfg.APIKeys != nil {
+		apiKey = mid.APIKey(cfg.APIKeys, cfg.RateLimits, cfg.Auth == nil)
+	}
+	if cfg.Auth != nil {
+		authen = mid.Authenticate(cfg.Auth)
+	}
+	if cfg.Policy != nil {
+		authz = mid.AuthorizePolicy(cfg.Policy)
+	}
+
+	// Requests are checked against the spec once the caller is known
+	var spec web.Middleware
+	if cfg.OpenAPI != nil {
+		spec = mid.OpenAPI(*cfg.OpenAPI)
+	}
+	protected := []web.Middleware{apiKey, authen, routeLimit, authz, spec}
+
+	// POST requests are replayed for retries with the same Idempotency-Key
+	var idem web.Middleware
+	if cfg.IdempotencyTTL > 0 {
+		if cfg.Idempotency == nil {
+			cfg.Idempotency = idempotency.NewMemoryStore()
+		}
+		idem = mid.Idempotency(mid.IdempotencyConfig{
+			Store:  cfg.Idempotency,
+			TTL:    cfg.IdempotencyTTL,
+			Caller: mid.RateLimitBySubject(clientIP),
+		})
+	}
+	post := []web.Middleware{apiKey, authen, routeLimit, authz, spec, idem}
 
 	// Yourservice Handlers
-	app.Handle(http.MethodPost, "/create", y.create)
+	app.Handle(http.MethodPost, "/create", y.create, post...).Describe(web.Doc{
+		Summary:  "Create an entity from a value",
+		Tags:     []string{"entities"},
+		Request:  createRequest{},
+		Response: statusResponse{},
+	})
+	app.Handle(http.MethodGet, "/entities", y.listEntities, protected...).Describe(web.Doc{
+		Summary:  "List entities a page at a time",
+		Tags:     []string{"entities"},
+		Query:    listQuery{},
+		Response: web.Page[yourservice.Entity]{},
+	})
+	app.Handle(http.MethodPost, "/entities", y.createEntity, post...).Describe(web.Doc{
+		Summary:  "Create an entity",
+		Tags:     []string{"entities"},
+		Request:  yourservice.NewEntity{},
+		Response: yourservice.Entity{},
+		Status:   http.StatusCreated,
+	})
+	app.Handle(http.MethodPost, "/entities/:id", y.createEntity, post...).Describe(web.Doc{
+		Summary:  "Create an entity with the given ID",
+		Tags:     []string{"entities"},
+		Request:  yourservice.NewEntity{},
+		Response: yourservice.Entity{},
+		Status:   http.StatusCreated,
+	})
+	app.Handle(http.MethodGet, "/entities/:id", y.getEntity, protected...).Describe(web.Doc{
+		Summary:  "Get an entity",
+		Tags:     []string{"entities"},
+		Response: yourservice.Entity{},
+	})
+	app.Handle(http.MethodPut, "/entities/:id", y.updateEntity, protected...).Describe(web.Doc{
+		Summary:  "Replace an entity",
+		Tags:     []string{"entities"},
+		Request:  yourservice.UpdateEntity{},
+		Response: yourservice.Entity{},
+	})
+	app.Handle(http.MethodPatch, "/entities/:id", y.patchEntity, protected...).Describe(web.Doc{
+		Summary:  "Update the fields of an entity that are set",
+		Tags:     []string{"entities"},
+		Request:  yourservice.PatchEntity{},
+		Response: yourservice.Entity{},
+	})
+	app.Handle(http.MethodDelete, "/entities/:id", y.deleteEntity, protected...).Describe(web.Doc{
+		Summary: "Delete an entity",
+		Tags:    []string{"entities"},
+		Status:  http.StatusNoContent,
+	})
+
+	// API key admin Handlers, always restricted to the admin role
+	if cfg.APIKeys != nil {
+		ak := apikeys{Service: cfg.APIKeys}
+		admin := []web.Middleware{apiKey, authen, routeLimit, mid.Authorize("admin"), spec}
+		app.Handle(http.MethodPost, "/admin/apikeys", ak.issue, admin...).Describe(web.Doc{
+			Summary:  "Issue an API key, its secret is only returned once",
+			Tags:     []string{"apikeys"},
+			Request:  apikey.NewKey{},
+			Response: issueResponse{},
+			Status:   http.StatusCreated,
+		})
+		app.Handle(http.MethodGet, "/admin/apikeys", ak.list, admin...).Describe(web.Doc{
+			Summary:  "List API keys without their secrets",
+			Tags:     []string{"apikeys"},
+			Response: []keyResponse{},
+		})
+		app.Handle(http.MethodDelete, "/admin/apikeys/:id", ak.revoke, admin...).Describe(web.Doc{
+			Summary: "Revoke an API key",
+			Tags:    []string{"apikeys"},
+			Status:  http.StatusNoContent,
+		})
+	}
 	return app
 
 }
 
-// Init will initialise the Service
-func Init(db yourservice.Store, log i.Logger) Yourservice {
+// Init will initialise the Service, Store calls are traced and measured in
+// reg
+func Init(db yourservice.Store, log *slog.Logger, reg prometheus.Registerer) Yourservice {
 
 	// Initialise services
 	y := Yourservice{
 		Service: &yourservice.Service{
-			Log:   log,
-			Store: db,
+			Log:   logger.NewPrintf(log),
+			Store: yourservice.TraceStore(yourservice.MeasureStore(db, reg)),
 		},
 	}
 	return y
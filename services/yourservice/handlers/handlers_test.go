@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/foundation/auth"
+	"dev/yourservice.git/foundation/logger"
+	"dev/yourservice.git/services/yourservice/handlers"
+	apikey_db "dev/yourservice.git/thirdparty/apikey-db"
+	memory_db "dev/yourservice.git/thirdparty/memory-db"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// update rewrites the golden files with the current output.
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestOpenAPI compares the served OpenAPI document with the golden file so
+// changes to the API show up in review. Run go test -update to accept them.
+func TestOpenAPI(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db, err := memory_db.NewClient(logger.NewPrintf(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDB, err := apikey_db.NewClient(logger.NewPrintf(log), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewRegistry()
+	app := handlers.API(log, handlers.Init(db, log, reg), make(chan os.Signal, 1), handlers.APIConfig{
+		Build:    "test",
+		Registry: reg,
+		APIKeys:  &apikey.Service{Log: logger.NewPrintf(log), Store: keyDB},
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %v, want %v", w.Code, http.StatusOK)
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, w.Body.Bytes(), "", "  "); err != nil {
+		t.Fatalf("decoding document: %v", err)
+	}
+	got.WriteByte('\n')
+
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("document differs from %v, run go test -update to accept the changes:\n%s", golden, got.Bytes())
+	}
+}
+
+// TestAPIKeyPolicy checks the policy applies to API keys when bearer tokens
+// are not enabled.
+func TestAPIKeyPolicy(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db, err := memory_db.NewClient(logger.NewPrintf(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDB, err := apikey_db.NewClient(logger.NewPrintf(log), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := auth.LoadPolicy(filepath.Join("..", "..", "..", "configs", "policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &apikey.Service{Log: logger.NewPrintf(log), Store: keyDB}
+	_, secret, err := keys.Issue(context.Background(), apikey.NewKey{Name: "reader", Scopes: []string{"entities:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewRegistry()
+	app := handlers.API(log, handlers.Init(db, log, reg), make(chan os.Signal, 1), handlers.APIConfig{
+		Registry: reg,
+		Policy:   policy,
+		APIKeys:  keys,
+	})
+
+	tests := []struct {
+		method string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodPost, `{"Name": "a", "Value": "b"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/entities", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-API-Key", secret)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("got status %v, want %v: %s", w.Code, tt.status, w.Body.Bytes())
+			}
+		})
+	}
+}
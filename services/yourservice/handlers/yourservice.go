@@ -2,17 +2,37 @@ package handlers
 
 import (
 	"context"
+	"dev/yourservice.git/business/yourservice"
 	"dev/yourservice.git/foundation/web"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
+// createRequest is the body of the create endpoint
+type createRequest struct {
+	Value string `validate:"required"`
+}
+
+// statusResponse reports the outcome of a request without data of its own
+type statusResponse struct {
+	Status string `json:"Status"`
+}
+
+// listQuery documents the query params of the list endpoint
+type listQuery struct {
+	After string `json:"after"`
+	Limit int    `json:"limit" validate:"omitempty,min=1"`
+}
+
 // create ...
 func (y Yourservice) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
 	// Get request data
-	var request = struct {
-		Value string `validate:"required"`
-	}{}
+	var request createRequest
 
 	// Decode, sanitize & validate request
 	err := web.Decode(r, &request)
@@ -24,17 +44,182 @@ func (y Yourservice) create(ctx context.Context, w http.ResponseWriter, r *http.
 	y.Service.Log.Printf("Creating...")
 
 	// Create
-	err = y.Service.Create(ctx)
+	_, err = y.Service.Create(ctx, yourservice.NewEntity{Value: request.Value})
 	if err != nil {
 		return err
 	}
 
 	// Send response data
-	response := struct {
-		Status string `json:"Status"`
-	}{
+	response := statusResponse{
 		Status: "Success",
 	}
 	return web.Respond(ctx, w, response, http.StatusOK)
 
 }
+
+// createEntity creates an entity, using the :id path param as its ID when
+// one is supplied
+func (y Yourservice) createEntity(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Decode, sanitize & validate request
+	var ne yourservice.NewEntity
+	err := web.Decode(r, &ne)
+	if err != nil {
+		return err
+	}
+	if id := web.Params(r)["id"]; id != "" {
+		ne.ID = id
+	}
+
+	// Create
+	e, err := y.Service.Create(ctx, ne)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	setETag(w, e.Version)
+	return web.Respond(ctx, w, e, http.StatusCreated)
+
+}
+
+// getEntity returns the entity identified by the :id path param
+func (y Yourservice) getEntity(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Get
+	e, err := y.Service.Get(ctx, web.Params(r)["id"])
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	setETag(w, e.Version)
+	return web.Respond(ctx, w, e, http.StatusOK)
+
+}
+
+// updateEntity replaces the entity identified by the :id path param
+func (y Yourservice) updateEntity(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Get the expected version
+	version, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+
+	// Decode, sanitize & validate request
+	var ue yourservice.UpdateEntity
+	err = web.Decode(r, &ue)
+	if err != nil {
+		return err
+	}
+
+	// Update
+	e, err := y.Service.Update(ctx, web.Params(r)["id"], ue, version)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	setETag(w, e.Version)
+	return web.Respond(ctx, w, e, http.StatusOK)
+
+}
+
+// patchEntity partially updates the entity identified by the :id path param
+func (y Yourservice) patchEntity(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Get the expected version
+	version, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+
+	// Decode, sanitize & validate request
+	var pe yourservice.PatchEntity
+	err = web.Decode(r, &pe)
+	if err != nil {
+		return err
+	}
+
+	// Patch
+	e, err := y.Service.Patch(ctx, web.Params(r)["id"], pe, version)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	setETag(w, e.Version)
+	return web.Respond(ctx, w, e, http.StatusOK)
+
+}
+
+// deleteEntity removes the entity identified by the :id path param
+func (y Yourservice) deleteEntity(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Get the expected version
+	version, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+
+	// Delete
+	err = y.Service.Delete(ctx, web.Params(r)["id"], version)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+
+}
+
+// listEntities returns a page of entities. The after and limit query params
+// control paging.
+func (y Yourservice) listEntities(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	// Get paging params
+	filter := yourservice.ListFilter{
+		After: web.GetParam(r, "after"),
+	}
+	if limit := web.GetParam(r, "limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return web.Errorf("limit must be a positive integer, but got [%v]", limit)
+		}
+		filter.Limit = n
+	}
+
+	// List
+	es, next, err := y.Service.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Send response data
+	response := web.Page[yourservice.Entity]{
+		Items: es,
+		Next:  next,
+	}
+	return web.Respond(ctx, w, response, http.StatusOK)
+
+}
+
+// ifMatch returns the entity version from the If-Match header, or 0 when the
+// header is not set
+func ifMatch(r *http.Request) (int64, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(h, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, web.NewError(errors.Errorf("invalid If-Match header [%v]", h))
+	}
+	return version, nil
+}
+
+// setETag sets the ETag header to the entity version
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
@@ -0,0 +1,279 @@
+package apikey_db
+
+import (
+	"context"
+	"dev/yourservice.git/business/apikey"
+	"dev/yourservice.git/business/i"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Constants
+const (
+	// missTTL is how long an unknown ID is answered from memory before the
+	// file is checked for it again.
+	missTTL = 5 * time.Second
+
+	// maxMisses bounds the remembered unknown IDs, they are forgotten all
+	// at once when it is reached.
+	maxMisses = 1024
+)
+
+// APIKeyDB keeps API keys in memory and, when a path is set, persists them
+// to a JSON file that is atomically replaced on every change. Keys are only
+// ever added or revoked so processes sharing the file, such as the server
+// and the apikey command, merge its contents under a file lock before every
+// save. Lookups reread the file when it changed, so keys issued or revoked by
+// another process take effect on the next request.
+type APIKeyDB struct {
+	Log i.Logger
+
+	path    string
+	mu      sync.RWMutex
+	keys    map[string]apikey.Key
+	misses  map[string]time.Time
+	modTime time.Time
+	size    int64
+}
+
+// Close will return dispose the client
+func (a *APIKeyDB) Close() {
+
+	// Every change is already persisted
+	return
+
+}
+
+// NewClient loads the keys in path. An empty path keeps keys in memory only.
+func NewClient(log i.Logger, path string) (*APIKeyDB, error) {
+
+	a := APIKeyDB{
+		Log:    log,
+		path:   path,
+		keys:   make(map[string]apikey.Key),
+		misses: make(map[string]time.Time),
+	}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+
+}
+
+// Create stores a new key
+func (a *APIKeyDB) Create(ctx context.Context, k apikey.Key) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.keys[k.ID]; exists {
+		return errors.Errorf("api key [%v] already exists", k.ID)
+	}
+	a.keys[k.ID] = k
+	if err := a.save(); err != nil {
+		delete(a.keys, k.ID)
+		return err
+	}
+	return nil
+}
+
+// Get returns the key with the specified ID
+func (a *APIKeyDB) Get(ctx context.Context, id string) (apikey.Key, error) {
+
+	// Answer from memory while the file is unchanged. Recently unknown IDs
+	// skip the check so guessed IDs do not reach the file system.
+	a.mu.RLock()
+	k, exists := a.keys[id]
+	missed := !exists && time.Now().Before(a.misses[id])
+	var changed bool
+	var err error
+	if !missed {
+		changed, err = a.changed()
+	}
+	a.mu.RUnlock()
+	switch {
+	case missed:
+		return apikey.Key{}, errors.Wrapf(apikey.ErrNotFound, "id [%v]", id)
+	case err != nil:
+		return apikey.Key{}, err
+	case exists && !changed:
+		return k, nil
+	}
+
+	// Another process issued or revoked keys, or the ID is unknown
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(); err != nil {
+		return apikey.Key{}, err
+	}
+	k, exists = a.keys[id]
+	if !exists {
+		if len(a.misses) >= maxMisses {
+			a.misses = make(map[string]time.Time)
+		}
+		a.misses[id] = time.Now().Add(missTTL)
+		return apikey.Key{}, errors.Wrapf(apikey.ErrNotFound, "id [%v]", id)
+	}
+	return k, nil
+}
+
+// Update replaces a stored key
+func (a *APIKeyDB) Update(ctx context.Context, k apikey.Key) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	old, exists := a.keys[k.ID]
+	if !exists {
+		return errors.Wrapf(apikey.ErrNotFound, "id [%v]", k.ID)
+	}
+	a.keys[k.ID] = k
+	if err := a.save(); err != nil {
+		a.keys[k.ID] = old
+		return err
+	}
+	return nil
+}
+
+// List returns all keys ordered by creation time
+func (a *APIKeyDB) List(ctx context.Context) ([]apikey.Key, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	keys := make([]apikey.Key, 0, len(a.keys))
+	for _, k := range a.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// changed reports whether the file differs from the one last read.
+func (a *APIKeyDB) changed() (bool, error) {
+	if a.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "reading api keys")
+	}
+	return !info.ModTime().Equal(a.modTime) || info.Size() != a.size, nil
+}
+
+// load merges the keys in the file into memory when the file changed since
+// it was last read.
+func (a *APIKeyDB) load() error {
+	changed, err := a.changed()
+	if err != nil || !changed {
+		return err
+	}
+	return a.merge()
+}
+
+// merge reads the file into memory. Keys missing from memory are added and a
+// revocation on either side wins.
+func (a *APIKeyDB) merge() error {
+	if a.path == "" {
+		return nil
+	}
+
+	// The file is replaced by renames, stat the one being read
+	f, err := os.Open(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "reading api keys")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "reading api keys")
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return errors.Wrap(err, "reading api keys")
+	}
+
+	var keys []apikey.Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return errors.Wrap(err, "decoding api keys")
+	}
+	for _, k := range keys {
+		if current, exists := a.keys[k.ID]; exists && (current.RevokedAt != nil || k.RevokedAt == nil) {
+			continue
+		}
+		a.keys[k.ID] = k
+	}
+	a.modTime, a.size = info.ModTime(), info.Size()
+
+	// Unknown IDs may have been issued since
+	a.misses = make(map[string]time.Time)
+	return nil
+}
+
+// save merges the keys written by other processes, then writes all keys to a
+// temporary file and renames it over the old one. Both happen under a lock
+// on a file next to it so concurrent writers do not lose each other's keys.
+func (a *APIKeyDB) save() error {
+	if a.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(a.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := a.merge(); err != nil {
+		return err
+	}
+
+	keys := make([]apikey.Key, 0, len(a.keys))
+	for _, k := range a.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := a.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "writing api keys")
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "writing api keys")
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "syncing api keys")
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return errors.Wrap(err, "replacing api keys")
+	}
+
+	// Our own write holds nothing new to merge
+	if info, err := os.Stat(a.path); err == nil {
+		a.modTime, a.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package apikey_db_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/apikey"
+	apikey_db "dev/yourservice.git/thirdparty/apikey-db"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// newTestDB returns a client of the keys in path.
+func newTestDB(t *testing.T, path string) *apikey_db.APIKeyDB {
+	t.Helper()
+	db, err := apikey_db.NewClient(log.New(io.Discard, "", 0), path)
+	if err != nil {
+		t.Fatalf("opening api keys: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// newKey returns a key ready to be created.
+func newKey(id string) apikey.Key {
+	return apikey.Key{ID: id, Name: "key " + id, Hash: "hash " + id, CreatedAt: time.Now().UTC()}
+}
+
+func TestMemoryOnly(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, "")
+
+	if err := db.Create(ctx, newKey("a")); err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	if err := db.Create(ctx, newKey("a")); err == nil {
+		t.Fatal("created a duplicate key")
+	}
+	if _, err := db.Get(ctx, "missing"); !errors.Is(err, apikey.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+	if err := db.Update(ctx, newKey("missing")); !errors.Is(err, apikey.ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	k := newKey("a")
+	k.Name = "renamed"
+	if err := db.Update(ctx, k); err != nil {
+		t.Fatalf("updating: %v", err)
+	}
+	got, err := db.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if got.Name != "renamed" {
+		t.Fatalf("got name [%v], want [renamed]", got.Name)
+	}
+}
+
+func TestPersisted(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "apikeys.json")
+
+	db := newTestDB(t, path)
+	for _, id := range []string{"b", "a"} {
+		if err := db.Create(ctx, newKey(id)); err != nil {
+			t.Fatalf("creating: %v", err)
+		}
+	}
+
+	keys, err := newTestDB(t, path).List(ctx)
+	if err != nil {
+		t.Fatalf("listing: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "b" || keys[1].ID != "a" {
+		t.Fatalf("reopened keys %+v, want b and a in creation order", keys)
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	server, command := newTestDB(t, path), newTestDB(t, path)
+
+	// A key issued by another process is found
+	if err := command.Create(ctx, newKey("a")); err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	if _, err := server.Get(ctx, "a"); err != nil {
+		t.Fatalf("getting a key issued elsewhere: %v", err)
+	}
+
+	// A cached key revoked by another process is revoked on the next lookup
+	k := newKey("a")
+	now := time.Now().UTC()
+	k.RevokedAt = &now
+	if err := command.Update(ctx, k); err != nil {
+		t.Fatalf("revoking: %v", err)
+	}
+	got, err := server.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if got.RevokedAt == nil {
+		t.Fatal("a key revoked elsewhere is still active")
+	}
+
+	// A stale copy does not undo the revocation
+	if err := server.Create(ctx, newKey("b")); err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	got, err = newTestDB(t, path).Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if got.RevokedAt == nil {
+		t.Fatal("the revocation was lost by a later save")
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+
+	const writers, keys = 4, 10
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		db := newTestDB(t, path)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				if err := db.Create(ctx, newKey(fmt.Sprintf("%v-%v", w, i))); err != nil {
+					t.Errorf("creating: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	got, err := newTestDB(t, path).List(ctx)
+	if err != nil {
+		t.Fatalf("listing: %v", err)
+	}
+	if len(got) != writers*keys {
+		t.Fatalf("got %v keys, want %v", len(got), writers*keys)
+	}
+}
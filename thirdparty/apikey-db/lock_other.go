@@ -0,0 +1,9 @@
+//go:build !unix
+
+package apikey_db
+
+// lockFile does not lock on this platform, only writers within the process
+// are serialized.
+func lockFile(name string) (func(), error) {
+	return func() {}, nil
+}
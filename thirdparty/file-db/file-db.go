@@ -0,0 +1,484 @@
+package file_db
+
+import (
+	"bufio"
+	"dev/yourservice.git/business/i"
+	"dev/yourservice.git/business/yourservice"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Config is the required properties to use the database.
+type Config struct {
+	// Dir is the directory holding the snapshot and log files.
+	Dir string
+
+	// CompactEvery is the number of log records after which the log is
+	// compacted into a new snapshot. Zero disables compaction.
+	CompactEvery int
+}
+
+// File names used inside Config.Dir.
+const (
+	snapshotFile = "snapshot.json"
+	logPrefix    = "wal-"
+	logSuffix    = ".log"
+)
+
+// headerSize is the size of a log record header: a 4 byte payload length, a
+// 4 byte CRC-32C of the length and a 4 byte CRC-32C of the payload. The
+// length has its own checksum so a damaged length is not mistaken for a
+// record cut short by the end of the log.
+const headerSize = 12
+
+// maxRecordSize bounds the payload length read from a record header so a
+// corrupt length is not allocated.
+const maxRecordSize = 64 << 20
+
+// errTorn is returned by readRecord for a record cut short by the end of the
+// log, as left by a crash mid-write.
+var errTorn = errors.New("torn record")
+
+// crcTable is used to checksum log records.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Set of log record operations.
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// record is a single entry in the write-ahead log.
+type record struct {
+	Op     string             `json:"Op"`
+	Entity yourservice.Entity `json:"Entity"`
+	ID     string             `json:"ID,omitempty"`
+}
+
+// snapshot is the compacted state of the database. Gen is the generation of
+// the log that must be replayed on top of it.
+type snapshot struct {
+	Gen      int64                `json:"Gen"`
+	Entities []yourservice.Entity `json:"Entities"`
+}
+
+// FileDB is an embedded database that keeps all entities in memory and
+// persists every write to an fsync'd append-only log in Config.Dir. The log
+// is periodically compacted into a snapshot.
+type FileDB struct {
+	Log i.Logger
+
+	cfg      Config
+	mu       sync.RWMutex
+	entities map[string]yourservice.Entity
+	ids      []string
+	gen      int64
+	wal      *os.File
+	records  int
+}
+
+// Close will return dispose the client
+func (f *FileDB) Close() {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Flush and close the log
+	if f.wal == nil {
+		return
+	}
+	if err := f.wal.Sync(); err != nil {
+		f.Log.Printf("file-db: sync log: %v", err)
+	}
+	if err := f.wal.Close(); err != nil {
+		f.Log.Printf("file-db: close log: %v", err)
+	}
+	f.wal = nil
+
+}
+
+// NewClient opens the database in cfg.Dir, creating the directory if needed,
+// and recovers its state from the snapshot and log. A torn record at the end
+// of the log, left by a crash mid-write, is truncated. A damaged record
+// followed by more data is corruption and fails the open.
+func NewClient(log i.Logger, cfg Config) (*FileDB, error) {
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "creating data dir")
+	}
+	f := FileDB{
+		Log:      log,
+		cfg:      cfg,
+		entities: make(map[string]yourservice.Entity),
+	}
+
+	// Load the latest snapshot
+	if err := f.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	// Replay the current log and open it for appending
+	if err := f.replay(); err != nil {
+		return nil, err
+	}
+
+	// Remove logs made obsolete by the snapshot
+	if err := f.removeOldLogs(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("file-db: recovered [%v] entities from [%v]", len(f.ids), cfg.Dir)
+	return &f, nil
+
+}
+
+// loadSnapshot reads the snapshot file if it exists.
+func (f *FileDB) loadSnapshot() error {
+
+	data, err := os.ReadFile(filepath.Join(f.cfg.Dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "reading snapshot")
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return errors.Wrap(err, "decoding snapshot")
+	}
+	f.gen = snap.Gen
+	for _, e := range snap.Entities {
+		f.put(e)
+	}
+	return nil
+
+}
+
+// replay applies every intact record of the current log and leaves the log
+// open for appending after the last intact record.
+func (f *FileDB) replay() error {
+
+	wal, err := os.OpenFile(f.logPath(f.gen), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "opening log")
+	}
+	info, err := wal.Stat()
+	if err != nil {
+		wal.Close()
+		return errors.Wrap(err, "reading log size")
+	}
+	size := info.Size()
+
+	// Apply records until the end of the log or the first torn record. Only
+	// the last record can be torn, a damaged record with data after it means
+	// the log is corrupt and truncating would silently drop writes.
+	var offset int64
+	r := bufio.NewReader(wal)
+	for {
+		rec, n, err := readRecord(r, size-offset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, errTorn) && offset+n < size {
+				wal.Close()
+				return errors.Wrapf(err, "log [%v] is corrupt at offset [%v]", wal.Name(), offset)
+			}
+			f.Log.Printf("file-db: truncating log [%v] at offset [%v]: %v", wal.Name(), offset, err)
+			break
+		}
+		f.apply(rec)
+		offset += n
+		f.records++
+	}
+
+	// Drop any torn data and position the file for appending
+	if err := wal.Truncate(offset); err != nil {
+		wal.Close()
+		return errors.Wrap(err, "truncating log")
+	}
+	if _, err := wal.Seek(offset, io.SeekStart); err != nil {
+		wal.Close()
+		return errors.Wrap(err, "seeking log")
+	}
+	if err := wal.Sync(); err != nil {
+		wal.Close()
+		return errors.Wrap(err, "syncing log")
+	}
+	f.wal = wal
+	return syncDir(f.cfg.Dir)
+
+}
+
+// readRecord reads one record from a log with remaining bytes left and
+// returns it with the length of the record. io.EOF is only returned when the
+// log ends cleanly between records and errTorn when it ends inside one. The
+// length is also returned with a damaged record so the caller can tell if it
+// is the last one.
+func readRecord(r io.Reader, remaining int64) (record, int64, error) {
+
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return record{}, 0, errors.Wrap(errTorn, "header")
+		}
+		return record{}, 0, err
+	}
+	if crc32.Checksum(header[0:4], crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return record{}, headerSize, errors.New("record length checksum mismatch")
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	sum := binary.BigEndian.Uint32(header[8:12])
+	n := int64(headerSize) + int64(size)
+
+	// Check the length before allocating the payload, it can be trusted
+	// once its checksum matches
+	if n > remaining {
+		return record{}, 0, errors.Wrapf(errTorn, "payload of [%v] bytes", size)
+	}
+	if size > maxRecordSize {
+		return record{}, n, errors.Errorf("record of [%v] bytes exceeds the maximum of [%v]", size, maxRecordSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return record{}, 0, errors.Wrap(errTorn, "payload")
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return record{}, n, errors.New("record checksum mismatch")
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, n, errors.Wrap(err, "decoding record")
+	}
+	return rec, n, nil
+
+}
+
+// append durably writes a record to the log before it is applied, then
+// compacts the log when it has grown past Config.CompactEvery records.
+func (f *FileDB) append(rec record) error {
+
+	if f.wal == nil {
+		return errors.New("file-db is closed")
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxRecordSize {
+		return errors.Errorf("record of [%v] bytes exceeds the maximum of [%v]", len(payload), maxRecordSize)
+	}
+	buf := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(buf[0:4], crcTable))
+	binary.BigEndian.PutUint32(buf[8:12], crc32.Checksum(payload, crcTable))
+	copy(buf[headerSize:], payload)
+
+	offset, err := f.wal.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "seeking log")
+	}
+	if _, err := f.wal.Write(buf); err != nil {
+		f.rollback(offset)
+		return errors.Wrap(err, "writing log")
+	}
+	if err := f.wal.Sync(); err != nil {
+		f.rollback(offset)
+		return errors.Wrap(err, "syncing log")
+	}
+	f.apply(rec)
+	f.records++
+
+	// A failed compaction keeps using the old log, or closes it if the new
+	// snapshot may have replaced the old one, so it is only logged
+	if f.cfg.CompactEvery > 0 && f.records >= f.cfg.CompactEvery {
+		if err := f.compact(); err != nil {
+			f.Log.Printf("file-db: compaction failed: %v", err)
+		}
+	}
+	return nil
+
+}
+
+// rollback removes a partially written record so that later records are not
+// appended after it. If that fails the log is closed and further writes are
+// refused, recovery on the next start truncates the torn record.
+func (f *FileDB) rollback(offset int64) {
+	if err := f.wal.Truncate(offset); err == nil {
+		if _, err = f.wal.Seek(offset, io.SeekStart); err == nil {
+			return
+		}
+	}
+	f.Log.Printf("file-db: could not roll back log, refusing further writes")
+	f.wal.Close()
+	f.wal = nil
+}
+
+// compact writes the current state to a new snapshot and starts a new log.
+// The new log is created before the snapshot that refers to it and each step
+// is durable before the next begins, so a crash at any point recovers either
+// the old snapshot and log or the new ones. If the snapshot may have replaced
+// the old one but is not known to be durable, writes to either log could be
+// lost on restart so the log is closed and further writes are refused.
+func (f *FileDB) compact() error {
+
+	// Create the new log
+	gen := f.gen + 1
+	wal, err := os.OpenFile(f.logPath(gen), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "creating log")
+	}
+	abort := func(err error) error {
+		wal.Close()
+		os.Remove(wal.Name())
+		return err
+	}
+	if err := wal.Sync(); err != nil {
+		return abort(errors.Wrap(err, "syncing log"))
+	}
+	if err := syncDir(f.cfg.Dir); err != nil {
+		return abort(err)
+	}
+
+	// Write the snapshot to a temporary file
+	snap := snapshot{
+		Gen:      gen,
+		Entities: make([]yourservice.Entity, 0, len(f.ids)),
+	}
+	for _, id := range f.ids {
+		snap.Entities = append(snap.Entities, f.entities[id])
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return abort(err)
+	}
+	tmp := filepath.Join(f.cfg.Dir, snapshotFile+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return abort(err)
+	}
+
+	// Atomically replace the old snapshot
+	if err := os.Rename(tmp, filepath.Join(f.cfg.Dir, snapshotFile)); err != nil {
+		return abort(errors.Wrap(err, "renaming snapshot"))
+	}
+	if err := syncDir(f.cfg.Dir); err != nil {
+		wal.Close()
+		f.Log.Printf("file-db: snapshot may not be durable, refusing further writes")
+		f.wal.Close()
+		f.wal = nil
+		return err
+	}
+
+	// Switch to the new log
+	old := f.wal
+	f.wal = wal
+	f.gen = gen
+	f.records = 0
+	if err := old.Close(); err != nil {
+		f.Log.Printf("file-db: close log: %v", err)
+	}
+	return f.removeOldLogs()
+
+}
+
+// removeOldLogs deletes the logs of generations older than the current one.
+func (f *FileDB) removeOldLogs() error {
+
+	names, err := filepath.Glob(filepath.Join(f.cfg.Dir, logPrefix+"*"+logSuffix))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), logPrefix), logSuffix)
+		gen, err := strconv.ParseInt(base, 10, 64)
+		if err != nil || gen >= f.gen {
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			return errors.Wrap(err, "removing old log")
+		}
+	}
+	return nil
+
+}
+
+// logPath returns the path of the log for the specified generation.
+func (f *FileDB) logPath(gen int64) string {
+	return filepath.Join(f.cfg.Dir, fmt.Sprintf("%v%012d%v", logPrefix, gen, logSuffix))
+}
+
+// apply applies a record to the in-memory state.
+func (f *FileDB) apply(rec record) {
+	switch rec.Op {
+	case opPut:
+		f.put(rec.Entity)
+	case opDelete:
+		f.remove(rec.ID)
+	}
+}
+
+// put stores an entity, keeping the IDs ordered.
+func (f *FileDB) put(e yourservice.Entity) {
+	if _, exists := f.entities[e.ID]; !exists {
+		n := sort.SearchStrings(f.ids, e.ID)
+		f.ids = append(f.ids, "")
+		copy(f.ids[n+1:], f.ids[n:])
+		f.ids[n] = e.ID
+	}
+	f.entities[e.ID] = e
+}
+
+// remove deletes an entity.
+func (f *FileDB) remove(id string) {
+	if _, exists := f.entities[id]; !exists {
+		return
+	}
+	delete(f.entities, id)
+	n := sort.SearchStrings(f.ids, id)
+	f.ids = append(f.ids[:n], f.ids[n+1:]...)
+}
+
+// writeFileSync writes data to the named file and fsyncs it.
+func writeFileSync(name string, data []byte) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir fsyncs a directory so that created, renamed and removed files are
+// durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return errors.Wrap(err, "syncing dir")
+	}
+	return nil
+}
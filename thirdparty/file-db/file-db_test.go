@@ -0,0 +1,260 @@
+package file_db
+
+import (
+	"context"
+	"dev/yourservice.git/business/yourservice"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// open opens the database in dir, failing the test on error.
+func open(t *testing.T, dir string, compactEvery int) *FileDB {
+	t.Helper()
+	db, err := NewClient(log.New(io.Discard, "", 0), Config{Dir: dir, CompactEvery: compactEvery})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	return db
+}
+
+// create stores entities with the specified IDs, failing the test on error.
+func create(t *testing.T, db *FileDB, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := db.Create(context.Background(), yourservice.Entity{ID: id, Value: id}); err != nil {
+			t.Fatalf("creating [%v]: %v", id, err)
+		}
+	}
+}
+
+// expect fails the test unless db holds exactly the entities with ids.
+func expect(t *testing.T, db *FileDB, ids ...string) {
+	t.Helper()
+	if len(db.ids) != len(ids) {
+		t.Fatalf("got entities %v, want %v", db.ids, ids)
+	}
+	for _, id := range ids {
+		if _, err := db.Get(context.Background(), id); err != nil {
+			t.Fatalf("getting [%v]: %v", id, err)
+		}
+	}
+}
+
+// appendBytes appends data to the named file.
+func appendBytes(t *testing.T, name string, data []byte) {
+	t.Helper()
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// header returns a record header for a payload of size bytes with sum.
+func header(size uint32, sum uint32) []byte {
+	var h [headerSize]byte
+	binary.BigEndian.PutUint32(h[0:4], size)
+	binary.BigEndian.PutUint32(h[4:8], crc32.Checksum(h[0:4], crcTable))
+	binary.BigEndian.PutUint32(h[8:12], sum)
+	return h[:]
+}
+
+func TestRecoverTornRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"partial header", []byte{0, 0, 0}},
+		{"partial payload", append(header(100, 0), `{"Op":"put"`...)},
+		{"length past the end", header(0xffffffff, 0)},
+		{"checksum mismatch", append(header(2, 0), `{}`...)},
+		{"length checksum mismatch", []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			db := open(t, dir, 0)
+			create(t, db, "a", "b")
+			db.Close()
+
+			name := db.logPath(0)
+			info, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendBytes(t, name, tt.tail)
+
+			db = open(t, dir, 0)
+			expect(t, db, "a", "b")
+			if after, err := os.Stat(name); err != nil || after.Size() != info.Size() {
+				t.Fatalf("log not truncated to [%v] bytes: %v %v", info.Size(), after.Size(), err)
+			}
+
+			// Writes after recovery must survive the next restart
+			create(t, db, "c")
+			db.Close()
+			db = open(t, dir, 0)
+			defer db.Close()
+			expect(t, db, "a", "b", "c")
+		})
+	}
+}
+
+func TestCorruptRecordFailsOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+	}{
+		{"payload", headerSize + 1},
+
+		// A length running past the end must not pass for a torn record
+		{"length", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			db := open(t, dir, 0)
+			create(t, db, "a", "b")
+			db.Close()
+
+			// Damage the first record
+			name := db.logPath(0)
+			data, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[tt.offset] ^= 0xff
+			if err := os.WriteFile(name, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewClient(log.New(io.Discard, "", 0), Config{Dir: dir}); err == nil {
+				t.Fatal("opening a log corrupt before its last record should fail")
+			}
+			if after, err := os.ReadFile(name); err != nil || len(after) != len(data) {
+				t.Fatalf("corrupt log must not be truncated: %v bytes %v", len(after), err)
+			}
+		})
+	}
+}
+
+func TestRecoverInterruptedCompaction(t *testing.T) {
+
+	// writeSnapshot writes the snapshot of generation gen holding db's
+	// entities, as compact does before switching logs.
+	writeSnapshot := func(t *testing.T, db *FileDB, gen int64) {
+		snap := snapshot{Gen: gen}
+		for _, id := range db.ids {
+			snap.Entities = append(snap.Entities, db.entities[id])
+		}
+		data, err := json.Marshal(snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(db.cfg.Dir, snapshotFile), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		crash  func(t *testing.T, db *FileDB)
+		gen    int64
+		oldLog bool
+	}{
+		{
+			name: "after creating the new log",
+			crash: func(t *testing.T, db *FileDB) {
+				if err := os.WriteFile(db.logPath(1), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(db.cfg.Dir, snapshotFile+".tmp"), []byte("{"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			gen:    0,
+			oldLog: true,
+		},
+		{
+			name: "after replacing the snapshot",
+			crash: func(t *testing.T, db *FileDB) {
+				if err := os.WriteFile(db.logPath(1), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				writeSnapshot(t, db, 1)
+			},
+			gen:    1,
+			oldLog: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			db := open(t, dir, 0)
+			create(t, db, "a", "b", "c")
+			db.Close()
+			tt.crash(t, db)
+
+			db = open(t, dir, 0)
+			expect(t, db, "a", "b", "c")
+			if db.gen != tt.gen {
+				t.Fatalf("recovered generation [%v], want [%v]", db.gen, tt.gen)
+			}
+			if _, err := os.Stat(db.logPath(0)); (err == nil) != tt.oldLog {
+				t.Fatalf("old log exists [%v], want [%v]", err == nil, tt.oldLog)
+			}
+
+			create(t, db, "d")
+			db.Close()
+			db = open(t, dir, 0)
+			defer db.Close()
+			expect(t, db, "a", "b", "c", "d")
+		})
+	}
+}
+
+func TestCompactionFailureKeepsLog(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, 2)
+
+	// A directory in place of the next log makes compaction fail
+	if err := os.Mkdir(db.logPath(1), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	create(t, db, "a", "b", "c")
+	if db.gen != 0 {
+		t.Fatalf("failed compaction switched to generation [%v]", db.gen)
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err == nil {
+		t.Fatal("failed compaction replaced the snapshot")
+	}
+	db.Close()
+
+	if err := os.Remove(db.logPath(1)); err != nil {
+		t.Fatal(err)
+	}
+	db = open(t, dir, 2)
+	expect(t, db, "a", "b", "c")
+
+	// Compaction succeeds once the log can be created
+	create(t, db, "d")
+	if db.gen != 1 {
+		t.Fatalf("compaction did not switch to generation 1, got [%v]", db.gen)
+	}
+	db.Close()
+	db = open(t, dir, 2)
+	defer db.Close()
+	expect(t, db, "a", "b", "c", "d")
+}
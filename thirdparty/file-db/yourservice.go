@@ -0,0 +1,125 @@
+package file_db
+
+import (
+	"context"
+	"dev/yourservice.git/business/yourservice"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Create stores a new entity, generating an ID when none is set
+func (f *FileDB) Create(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if _, exists := f.entities[e.ID]; exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrConflict, "id [%v] already exists", e.ID)
+	}
+
+	e.Version = 1
+	if err := f.append(record{Op: opPut, Entity: e}); err != nil {
+		return yourservice.Entity{}, err
+	}
+	return e, nil
+
+}
+
+// Get returns the entity with the specified ID
+func (f *FileDB) Get(ctx context.Context, id string) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	e, exists := f.entities[id]
+	if !exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	return e, nil
+
+}
+
+// Update replaces the entity if the stored Version matches e.Version
+func (f *FileDB) Update(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, exists := f.entities[e.ID]
+	if !exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrNotFound, "id [%v]", e.ID)
+	}
+	if current.Version != e.Version {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrConflict, "id [%v] is at version [%v], not [%v]", e.ID, current.Version, e.Version)
+	}
+
+	// The creation time can not be changed by an update
+	e.CreatedAt = current.CreatedAt
+	e.Version++
+	if err := f.append(record{Op: opPut, Entity: e}); err != nil {
+		return yourservice.Entity{}, err
+	}
+	return e, nil
+
+}
+
+// Delete removes the entity, a non-zero version must match the stored Version
+func (f *FileDB) Delete(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, exists := f.entities[id]
+	if !exists {
+		return errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	if version != 0 && current.Version != version {
+		return errors.Wrapf(yourservice.ErrConflict, "id [%v] is at version [%v], not [%v]", id, current.Version, version)
+	}
+
+	return f.append(record{Op: opDelete, ID: id})
+
+}
+
+// List returns up to filter.Limit entities with an ID greater than filter.After
+func (f *FileDB) List(ctx context.Context, filter yourservice.ListFilter) ([]yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Find the first ID after the cursor
+	n := 0
+	if filter.After != "" {
+		n = sort.Search(len(f.ids), func(i int) bool { return f.ids[i] > filter.After })
+	}
+
+	es := []yourservice.Entity{}
+	for _, id := range f.ids[n:] {
+		if filter.Limit > 0 && len(es) == filter.Limit {
+			break
+		}
+		es = append(es, f.entities[id])
+	}
+	return es, nil
+
+}
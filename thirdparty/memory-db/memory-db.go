@@ -0,0 +1,40 @@
+package memory_db
+
+import (
+	"dev/yourservice.git/business/i"
+	"dev/yourservice.git/business/yourservice"
+	"sync"
+)
+
+// MemoryDB is a concurrency-safe in-memory database for local development and
+// tests. Generated IDs are sequential so data is deterministic across runs.
+type MemoryDB struct {
+	Log i.Logger
+
+	mu       sync.RWMutex
+	entities map[string]yourservice.Entity
+	ids      []string
+	seq      int64
+}
+
+// Close will return dispose the client
+func (m *MemoryDB) Close() {
+
+	// Drop all data
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entities = make(map[string]yourservice.Entity)
+	m.ids = nil
+
+}
+
+// NewClient will return an empty in-memory database
+func NewClient(log i.Logger) (*MemoryDB, error) {
+
+	// Create the client
+	return &MemoryDB{
+		Log:      log,
+		entities: make(map[string]yourservice.Entity),
+	}, nil
+
+}
@@ -0,0 +1,195 @@
+package memory_db_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/yourservice"
+	memory_db "dev/yourservice.git/thirdparty/memory-db"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// newTestDB returns an empty database.
+func newTestDB(t *testing.T) *memory_db.MemoryDB {
+	t.Helper()
+	db, err := memory_db.NewClient(log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// newEntity returns an entity ready to be created.
+func newEntity(id string) yourservice.Entity {
+	now := time.Now().UTC()
+	return yourservice.Entity{ID: id, Name: "name " + id, Value: "value " + id, CreatedAt: now, UpdatedAt: now}
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	created, err := db.Create(ctx, newEntity("a"))
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	if created.Version != 1 {
+		t.Fatalf("created version [%v], want 1", created.Version)
+	}
+	if _, err := db.Create(ctx, newEntity("a")); !errors.Is(err, yourservice.ErrConflict) {
+		t.Fatalf("creating a duplicate: got %v, want ErrConflict", err)
+	}
+
+	// Generated IDs are sequential and skip taken ones
+	if _, err := db.Create(ctx, newEntity("000000000001")); err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	generated, err := db.Create(ctx, newEntity(""))
+	if err != nil {
+		t.Fatalf("creating without an ID: %v", err)
+	}
+	if generated.ID != "000000000002" {
+		t.Fatalf("generated ID [%v], want [000000000002]", generated.ID)
+	}
+}
+
+func TestCopyOnRead(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	e := newEntity("a")
+	if _, err := db.Create(ctx, e); err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	e.Value = "changed after create"
+
+	got, err := db.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	got.Value = "changed after get"
+	es, err := db.List(ctx, yourservice.ListFilter{})
+	if err != nil {
+		t.Fatalf("listing: %v", err)
+	}
+	es[0].Value = "changed after list"
+
+	// None of the changes reach the stored entity
+	stored, err := db.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if stored.Value != "value a" {
+		t.Fatalf("stored value is [%v], want [value a]", stored.Value)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	e, err := db.Create(ctx, newEntity("a"))
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+
+	e.Value = "changed"
+	e.CreatedAt = e.CreatedAt.Add(time.Hour)
+	updated, err := db.Update(ctx, e)
+	if err != nil {
+		t.Fatalf("updating: %v", err)
+	}
+	if updated.Version != 2 || updated.Value != "changed" || updated.CreatedAt.Equal(e.CreatedAt) {
+		t.Fatalf("updated %+v", updated)
+	}
+
+	tests := []struct {
+		name   string
+		entity yourservice.Entity
+		want   error
+	}{
+		{"stale version", e, yourservice.ErrConflict},
+		{"missing entity", newEntity("missing"), yourservice.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Update(ctx, tt.entity); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := db.Create(ctx, newEntity(id)); err != nil {
+			t.Fatalf("creating: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		version int64
+		want    error
+	}{
+		{"stale version", "a", 2, yourservice.ErrConflict},
+		{"matching version", "a", 1, nil},
+		{"any version", "b", 0, nil},
+		{"missing entity", "a", 0, yourservice.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := db.Delete(ctx, tt.id, tt.version); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, err := db.Get(ctx, "a"); !errors.Is(err, yourservice.ErrNotFound) {
+		t.Fatalf("getting a deleted entity: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, id := range []string{"c", "a", "b"} {
+		if _, err := db.Create(ctx, newEntity(id)); err != nil {
+			t.Fatalf("creating: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter yourservice.ListFilter
+		want   []string
+	}{
+		{"first page", yourservice.ListFilter{Limit: 2}, []string{"a", "b"}},
+		{"after cursor", yourservice.ListFilter{Limit: 2, After: "b"}, []string{"c"}},
+		{"past the end", yourservice.ListFilter{Limit: 2, After: "c"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := db.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			if len(es) != len(tt.want) {
+				t.Fatalf("got %v entities, want %v", len(es), tt.want)
+			}
+			for i, e := range es {
+				if e.ID != tt.want[i] {
+					t.Fatalf("entity [%v] is [%v], want [%v]", i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
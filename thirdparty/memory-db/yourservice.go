@@ -0,0 +1,136 @@
+package memory_db
+
+import (
+	"context"
+	"dev/yourservice.git/business/yourservice"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Create stores a new entity, generating a sequential ID when none is set
+func (m *MemoryDB) Create(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Generate an ID that is not taken yet
+	if e.ID == "" {
+		for {
+			m.seq++
+			e.ID = fmt.Sprintf("%012d", m.seq)
+			if _, exists := m.entities[e.ID]; !exists {
+				break
+			}
+		}
+	}
+	if _, exists := m.entities[e.ID]; exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrConflict, "id [%v] already exists", e.ID)
+	}
+
+	// Store the entity and keep the IDs ordered
+	e.Version = 1
+	m.entities[e.ID] = e
+	n := sort.SearchStrings(m.ids, e.ID)
+	m.ids = append(m.ids, "")
+	copy(m.ids[n+1:], m.ids[n:])
+	m.ids[n] = e.ID
+	return e, nil
+
+}
+
+// Get returns the entity with the specified ID
+func (m *MemoryDB) Get(ctx context.Context, id string) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, exists := m.entities[id]
+	if !exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	return e, nil
+
+}
+
+// Update replaces the entity if the stored Version matches e.Version
+func (m *MemoryDB) Update(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return yourservice.Entity{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.entities[e.ID]
+	if !exists {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrNotFound, "id [%v]", e.ID)
+	}
+	if current.Version != e.Version {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrConflict, "id [%v] is at version [%v], not [%v]", e.ID, current.Version, e.Version)
+	}
+
+	// The creation time can not be changed by an update
+	e.CreatedAt = current.CreatedAt
+	e.Version++
+	m.entities[e.ID] = e
+	return e, nil
+
+}
+
+// Delete removes the entity, a non-zero version must match the stored Version
+func (m *MemoryDB) Delete(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.entities[id]
+	if !exists {
+		return errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	if version != 0 && current.Version != version {
+		return errors.Wrapf(yourservice.ErrConflict, "id [%v] is at version [%v], not [%v]", id, current.Version, version)
+	}
+
+	delete(m.entities, id)
+	n := sort.SearchStrings(m.ids, id)
+	m.ids = append(m.ids[:n], m.ids[n+1:]...)
+	return nil
+
+}
+
+// List returns up to filter.Limit entities with an ID greater than filter.After
+func (m *MemoryDB) List(ctx context.Context, filter yourservice.ListFilter) ([]yourservice.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Find the first ID after the cursor
+	n := 0
+	if filter.After != "" {
+		n = sort.Search(len(m.ids), func(i int) bool { return m.ids[i] > filter.After })
+	}
+
+	es := []yourservice.Entity{}
+	for _, id := range m.ids[n:] {
+		if filter.Limit > 0 && len(es) == filter.Limit {
+			break
+		}
+		es = append(es, m.entities[id])
+	}
+	return es, nil
+
+}
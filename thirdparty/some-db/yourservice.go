@@ -2,13 +2,57 @@ package some_db
 
 import (
 	"context"
+	"dev/yourservice.git/business/yourservice"
+
+	"github.com/google/uuid"
 )
 
 // Create ...
-func (s *SomeDB) Create(ctx context.Context) error {
+func (s *SomeDB) Create(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
 
 	// Create and return the entity
 	println("Wrote entity to SomeDB")
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	e.Version = 1
+	return e, nil
+
+}
+
+// Get ...
+func (s *SomeDB) Get(ctx context.Context, id string) (yourservice.Entity, error) {
+
+	// Read and return the entity
+	println("Read entity from SomeDB")
+	return yourservice.Entity{ID: id, Version: 1}, nil
+
+}
+
+// Update ...
+func (s *SomeDB) Update(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+
+	// Update and return the entity
+	println("Updated entity in SomeDB")
+	e.Version++
+	return e, nil
+
+}
+
+// Delete ...
+func (s *SomeDB) Delete(ctx context.Context, id string, version int64) error {
+
+	// Delete the entity
+	println("Deleted entity from SomeDB")
 	return nil
 
 }
+
+// List ...
+func (s *SomeDB) List(ctx context.Context, filter yourservice.ListFilter) ([]yourservice.Entity, error) {
+
+	// List the entities
+	println("Listed entities from SomeDB")
+	return []yourservice.Entity{}, nil
+
+}
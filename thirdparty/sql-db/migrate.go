@@ -0,0 +1,159 @@
+package sql_db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// migrationFiles holds the schema migrations. Files are named
+// NNNN_description.sql and are applied in order of NNNN.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the postgres advisory lock key held while migrating so
+// that instances starting together do not apply the same migration twice.
+const migrationLock = 7_270_001
+
+// migration is a single versioned schema change.
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrate applies every migration that has not been applied yet. Each
+// migration runs in its own transaction together with the insert recording
+// it in schema_migrations. On postgres an advisory lock serializes concurrent
+// calls, other drivers are expected to serialize writers themselves.
+func (s *SQLDB) Migrate(ctx context.Context) error {
+
+	// Load the migrations
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	// Hold a single connection so the session level lock covers every step
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "acquiring connection")
+	}
+	defer conn.Close()
+	if s.cfg.Driver == "postgres" {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+			return errors.Wrap(err, "acquiring migration lock")
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLock); err != nil {
+				s.Log.Printf("sql-db: releasing migration lock: %v", err)
+			}
+		}()
+	}
+
+	// Make sure the bookkeeping table exists
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "creating schema_migrations")
+	}
+
+	// Find the applied versions
+	applied := make(map[int]bool)
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return errors.Wrap(err, "reading schema_migrations")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// Apply the missing ones in order
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if err := s.apply(ctx, conn, m); err != nil {
+			return errors.Wrapf(err, "applying migration [%v]", m.Name)
+		}
+		s.Log.Printf("sql-db: applied migration [%v]", m.Name)
+	}
+	return nil
+
+}
+
+// apply runs a migration on conn and records it.
+func (s *SQLDB) apply(ctx context.Context, conn *sql.Conn, m migration) error {
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`,
+		m.Version, time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+
+}
+
+// loadMigrations reads the embedded migrations sorted by version.
+func loadMigrations() ([]migration, error) {
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, errors.Errorf("migration [%v] has no numeric version prefix", name)
+		}
+		if other, exists := seen[version]; exists {
+			return nil, errors.Errorf("migrations [%v] and [%v] share version [%v]", other, name, version)
+		}
+		seen[version] = name
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{Version: version, Name: name, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+
+}
@@ -0,0 +1,10 @@
+-- Timestamps are always written in UTC, TIMESTAMP is used rather than
+-- TIMESTAMPTZ so the schema also runs on SQLite.
+CREATE TABLE entities (
+	id         VARCHAR(128) PRIMARY KEY,
+	name       VARCHAR(256) NOT NULL DEFAULT '',
+	value      TEXT         NOT NULL,
+	version    BIGINT       NOT NULL,
+	created_at TIMESTAMP    NOT NULL,
+	updated_at TIMESTAMP    NOT NULL
+);
@@ -0,0 +1,78 @@
+package sql_db
+
+import (
+	"context"
+	"database/sql"
+	"dev/yourservice.git/business/i"
+	"time"
+
+	// Register the postgres driver
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Config is the required properties to use the database.
+type Config struct {
+	// Driver is the database/sql driver name. Queries are written for
+	// postgres but only use SQL that SQLite understands too.
+	Driver          string
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// QueryTimeout bounds every query. Zero means no timeout.
+	QueryTimeout time.Duration
+}
+
+// SQLDB is a Store backed by a database/sql connection pool
+type SQLDB struct {
+	Log i.Logger
+
+	db  *sql.DB
+	cfg Config
+}
+
+// Close will return dispose the client
+func (s *SQLDB) Close() {
+
+	// Close the connection pool
+	if err := s.db.Close(); err != nil {
+		s.Log.Printf("sql-db: close: %v", err)
+	}
+
+}
+
+// NewClient opens the connection pool and verifies the database is reachable
+func NewClient(log i.Logger, cfg Config) (*SQLDB, error) {
+
+	// Open the pool
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening database")
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Check the database is reachable
+	s := SQLDB{Log: log, db: db, cfg: cfg}
+	ctx, cancel := s.withTimeout(context.Background())
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "pinging database")
+	}
+	return &s, nil
+
+}
+
+// withTimeout applies Config.QueryTimeout to ctx.
+func (s *SQLDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.cfg.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.cfg.QueryTimeout)
+}
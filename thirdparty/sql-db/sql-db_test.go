@@ -0,0 +1,182 @@
+package sql_db_test
+
+import (
+	"context"
+	"dev/yourservice.git/business/yourservice"
+	sql_db "dev/yourservice.git/thirdparty/sql-db"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	// Register the sqlite driver used as the test database
+	_ "modernc.org/sqlite"
+)
+
+// newTestDB returns a migrated SQLite database in a temporary directory. The
+// queries only use SQL shared with postgres so they run unchanged.
+func newTestDB(t *testing.T) *sql_db.SQLDB {
+	t.Helper()
+	db, err := sql_db.NewClient(log.New(io.Discard, "", 0), sql_db.Config{
+		Driver:       "sqlite",
+		DSN:          "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)",
+		MaxOpenConns: 1,
+		QueryTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrating database: %v", err)
+	}
+	return db
+}
+
+// newEntity returns an entity ready to be created.
+func newEntity(id string) yourservice.Entity {
+	now := time.Now().UTC().Truncate(time.Second)
+	return yourservice.Entity{ID: id, Name: "name " + id, Value: "value " + id, CreatedAt: now, UpdatedAt: now}
+}
+
+func TestMigrateTwice(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrating an up to date database: %v", err)
+	}
+}
+
+func TestCreateGet(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	want := newEntity("a")
+	created, err := db.Create(ctx, want)
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	if created.Version != 1 {
+		t.Fatalf("created version [%v], want 1", created.Version)
+	}
+
+	got, err := db.Get(ctx, "a")
+	if err != nil {
+		t.Fatalf("getting: %v", err)
+	}
+	if got.Name != want.Name || got.Value != want.Value || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := db.Create(ctx, want); !errors.Is(err, yourservice.ErrConflict) {
+		t.Fatalf("creating a duplicate: got %v, want ErrConflict", err)
+	}
+	if _, err := db.Get(ctx, "missing"); !errors.Is(err, yourservice.ErrNotFound) {
+		t.Fatalf("getting a missing entity: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	e, err := db.Create(ctx, newEntity("a"))
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+
+	e.Value = "changed"
+	e.UpdatedAt = e.UpdatedAt.Add(time.Minute)
+	updated, err := db.Update(ctx, e)
+	if err != nil {
+		t.Fatalf("updating: %v", err)
+	}
+	if updated.Version != 2 || updated.Value != "changed" || !updated.CreatedAt.Equal(e.CreatedAt) {
+		t.Fatalf("updated %+v", updated)
+	}
+
+	tests := []struct {
+		name   string
+		entity yourservice.Entity
+		want   error
+	}{
+		{"stale version", e, yourservice.ErrConflict},
+		{"missing entity", newEntity("missing"), yourservice.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Update(ctx, tt.entity); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := db.Create(ctx, newEntity(id)); err != nil {
+			t.Fatalf("creating: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		version int64
+		want    error
+	}{
+		{"stale version", "a", 2, yourservice.ErrConflict},
+		{"matching version", "a", 1, nil},
+		{"any version", "b", 0, nil},
+		{"missing entity", "a", 0, yourservice.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := db.Delete(ctx, tt.id, tt.version); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	for _, id := range []string{"c", "a", "b"} {
+		if _, err := db.Create(ctx, newEntity(id)); err != nil {
+			t.Fatalf("creating: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter yourservice.ListFilter
+		want   []string
+	}{
+		{"first page", yourservice.ListFilter{Limit: 2}, []string{"a", "b"}},
+		{"after cursor", yourservice.ListFilter{Limit: 2, After: "b"}, []string{"c"}},
+		{"past the end", yourservice.ListFilter{Limit: 2, After: "c"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, err := db.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			if len(es) != len(tt.want) {
+				t.Fatalf("got %v entities, want %v", len(es), tt.want)
+			}
+			for i, e := range es {
+				if e.ID != tt.want[i] {
+					t.Fatalf("entity [%v] is [%v], want [%v]", i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
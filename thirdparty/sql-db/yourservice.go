@@ -0,0 +1,202 @@
+package sql_db
+
+import (
+	"context"
+	"database/sql"
+	"dev/yourservice.git/business/yourservice"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// entityColumns is the column list matching scanEntity.
+const entityColumns = `id, name, value, version, created_at, updated_at`
+
+// Create inserts a new entity, generating an ID when none is set
+func (s *SQLDB) Create(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	e.Version = 1
+
+	// ON CONFLICT DO NOTHING keeps the conflict check portable across drivers
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO entities (`+entityColumns+`) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING`,
+		e.ID, e.Name, e.Value, e.Version, e.CreatedAt, e.UpdatedAt,
+	)
+	if err != nil {
+		return yourservice.Entity{}, errors.Wrap(err, "inserting entity")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return yourservice.Entity{}, err
+	}
+	if n == 0 {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrConflict, "id [%v] already exists", e.ID)
+	}
+	return e, nil
+
+}
+
+// Get returns the entity with the specified ID
+func (s *SQLDB) Get(ctx context.Context, id string) (yourservice.Entity, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return get(ctx, s.db, id)
+
+}
+
+// Update replaces the entity if the stored Version matches e.Version
+func (s *SQLDB) Update(ctx context.Context, e yourservice.Entity) (yourservice.Entity, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// The read back runs in the same transaction so it returns this write
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return yourservice.Entity{}, errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE entities SET name = $1, value = $2, version = version + 1, updated_at = $3
+		WHERE id = $4 AND version = $5`,
+		e.Name, e.Value, e.UpdatedAt, e.ID, e.Version,
+	)
+	if err != nil {
+		return yourservice.Entity{}, errors.Wrap(err, "updating entity")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return yourservice.Entity{}, err
+	}
+	if n == 0 {
+		return yourservice.Entity{}, missed(ctx, tx, e.ID, e.Version)
+	}
+
+	// Read back the stored row so CreatedAt reflects the database
+	stored, err := get(ctx, tx, e.ID)
+	if err != nil {
+		return yourservice.Entity{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return yourservice.Entity{}, errors.Wrap(err, "committing update")
+	}
+	return stored, nil
+
+}
+
+// Delete removes the entity, a non-zero version must match the stored Version
+func (s *SQLDB) Delete(ctx context.Context, id string, version int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var res sql.Result
+	var err error
+	if version == 0 {
+		res, err = s.db.ExecContext(ctx, `DELETE FROM entities WHERE id = $1`, id)
+	} else {
+		res, err = s.db.ExecContext(ctx, `DELETE FROM entities WHERE id = $1 AND version = $2`, id, version)
+	}
+	if err != nil {
+		return errors.Wrap(err, "deleting entity")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return missed(ctx, s.db, id, version)
+	}
+	return nil
+
+}
+
+// List returns up to filter.Limit entities with an ID greater than filter.After
+func (s *SQLDB) List(ctx context.Context, filter yourservice.ListFilter) ([]yourservice.Entity, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = yourservice.MaxListLimit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+entityColumns+` FROM entities WHERE id > $1 ORDER BY id LIMIT $2`,
+		filter.After, limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing entities")
+	}
+	defer rows.Close()
+
+	es := []yourservice.Entity{}
+	for rows.Next() {
+		e, err := scanEntity(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning entity")
+		}
+		es = append(es, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "listing entities")
+	}
+	return es, nil
+
+}
+
+// get returns the entity with the specified ID using q.
+func get(ctx context.Context, q querier, id string) (yourservice.Entity, error) {
+	row := q.QueryRowContext(ctx,
+		`SELECT `+entityColumns+` FROM entities WHERE id = $1`,
+		id,
+	)
+	e, err := scanEntity(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return yourservice.Entity{}, errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	if err != nil {
+		return yourservice.Entity{}, errors.Wrap(err, "selecting entity")
+	}
+	return e, nil
+}
+
+// missed explains why a conditional write affected no rows.
+func missed(ctx context.Context, q querier, id string, version int64) error {
+	var current int64
+	err := q.QueryRowContext(ctx, `SELECT version FROM entities WHERE id = $1`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrapf(yourservice.ErrNotFound, "id [%v]", id)
+	}
+	if err != nil {
+		return errors.Wrap(err, "selecting entity version")
+	}
+	return errors.Wrapf(yourservice.ErrConflict, "id [%v] is at version [%v], not [%v]", id, current, version)
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntity scans the columns listed in entityColumns.
+func scanEntity(row scanner) (yourservice.Entity, error) {
+	var e yourservice.Entity
+	err := row.Scan(&e.ID, &e.Name, &e.Value, &e.Version, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return yourservice.Entity{}, err
+	}
+	e.CreatedAt = e.CreatedAt.UTC()
+	e.UpdatedAt = e.UpdatedAt.UTC()
+	return e, nil
+}